@@ -1,20 +1,34 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
-
+	"strings"
 	"time"
 
+	"sonnda-api/internal/admin"
 	"sonnda-api/internal/auth"
+	"sonnda-api/internal/brdocs"
+	"sonnda-api/internal/cid10"
 	"sonnda-api/internal/database"
+	"sonnda-api/internal/doctor"
+	exam "sonnda-api/internal/exams"
+	"sonnda-api/internal/labparser"
+	"sonnda-api/internal/mail"
 	"sonnda-api/internal/middleware"
+	"sonnda-api/internal/patient"
+	"sonnda-api/internal/prevention"
+	"sonnda-api/internal/professional"
+	"sonnda-api/internal/rbac"
 	"sonnda-api/internal/user"
 
 	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
 )
 
 func main() {
+	_ = godotenv.Load()
 
 	//conectar db
 	database.Connect()
@@ -22,6 +36,20 @@ func main() {
 
 	//montar o gin e rotas
 	r := gin.Default()
+	// ClientIP (bloqueio de login por IP, auditoria) só lê X-Forwarded-For
+	// vindo dos proxies de TRUSTED_PROXIES; sem a variável, vale o IP da conexão
+	var proxies []string
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			proxies = append(proxies, strings.TrimSpace(p))
+		}
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		log.Fatalf("TRUSTED_PROXIES inválido: %v", err)
+	}
+	if err := brdocs.RegisterGinValidators(); err != nil {
+		log.Fatalf("Erro ao registrar validadores: %v", err)
+	}
 
 	// 🌐 Aplica o middleware de CORS
 	r.Use(middleware.SetupCors())
@@ -34,21 +62,159 @@ func main() {
 		})
 	})
 
+	//chaves JWT: RS256/EdDSA a partir do manifesto; HS256 só para desenvolvimento
+	var keys *auth.Keyring
+	if manifest := os.Getenv("JWT_KEYS_MANIFEST"); manifest != "" {
+		var err error
+		if keys, err = auth.LoadKeyring(manifest); err != nil {
+			log.Fatalf("Erro ao carregar chaves JWT: %v", err)
+		}
+		go keys.Watch(context.Background(), 5*time.Minute)
+	} else {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			log.Fatal("JWT_KEYS_MANIFEST or JWT_SECRET must be set")
+		}
+		log.Println("⚠️  JWT_KEYS_MANIFEST não definido: usando HS256 com JWT_SECRET")
+		keys = auth.NewHMACKeyring(secret)
+	}
+
 	//config
 	jwtMgr := auth.NewJWTManager(
-		os.Getenv("JWT_SECRET"),
+		keys,
 		"sonnda-api",
-		24*time.Hour,
+		15*time.Minute,
+		30*24*time.Hour,
 	)
 
+	revocations := auth.NewRevocationStore(db, 30*time.Second)
+	authn := auth.NewAuthenticator(jwtMgr, revocations)
+	policy := rbac.NewPolicy(rbac.NewRepository(db), time.Minute)
+	mailer := mail.NewFromEnv()
+	authCfg := auth.Config{
+		AppURL:           os.Getenv("APP_URL"),
+		PasswordResetTTL: time.Hour,
+		SigningSecret:    os.Getenv("TOKEN_SIGNING_SECRET"),
+
+		VerificationTTL:            48 * time.Hour,
+		VerificationResendInterval: 2 * time.Minute,
+		// médicos e admins acessam dados de pacientes: exigem e-mail confirmado
+		RequireVerifiedEmail: []user.Role{user.RoleDoctor, user.RoleAdmin},
+
+		InvitationTTL: 7 * 24 * time.Hour,
+
+		MFAIssuer:        "Sonnda",
+		MFAEncryptionKey: os.Getenv("MFA_ENCRYPTION_KEY"),
+		MFAChallengeTTL:  5 * time.Minute,
+		// quem lê dados de pacientes precisa de segundo fator
+		RequireMFA: []user.Role{user.RoleDoctor, user.RoleAdmin},
+
+		AccountLockout: auth.LockoutPolicy{
+			MaxFailures: 5,
+			BaseLockout: time.Minute,
+			MaxLockout:  time.Hour,
+			Window:      15 * time.Minute,
+		},
+		IPLockout: auth.LockoutPolicy{
+			MaxFailures: 20,
+			BaseLockout: time.Minute,
+			MaxLockout:  time.Hour,
+			Window:      15 * time.Minute,
+		},
+	}
+
+	if err := authCfg.Validate(); err != nil {
+		log.Fatalf("Configuração de autenticação inválida: %v", err)
+	}
+
+	// contadores de login no Postgres para que todas as réplicas concordem
+	attempts := auth.NewGormAttemptStore(db)
+	if os.Getenv("LOGIN_ATTEMPTS_STORE") == "memory" {
+		attempts = auth.NewMemoryAttemptStore()
+	}
+
+	// tabela CID-10 (embutida ou CID10_TABLE) para codificar problemas
+	cidCatalog, err := cid10.Default()
+	if err != nil {
+		log.Fatalf("Erro ao carregar tabela CID-10: %v", err)
+	}
+
+	// regras de prevenção (embutidas ou PREVENTION_RULES)
+	if _, err := prevention.Default(); err != nil {
+		log.Fatalf("Erro ao carregar regras de prevenção: %v", err)
+	}
+
+	// perfis de laudo por laboratório (embutidos mais LAB_PROFILES_DIR)
+	if _, err := labparser.Default(); err != nil {
+		log.Fatalf("Erro ao carregar perfis de laudos: %v", err)
+	}
+	// valores críticos de resultados (embutidos ou LAB_CRITICAL_VALUES)
+	if _, err := labparser.DefaultCriticalValues(); err != nil {
+		log.Fatalf("Erro ao carregar valores críticos: %v", err)
+	}
+
+	// cadastro de pacientes envia a mesma verificação de e-mail de /auth/register
+	verification := auth.NewVerificationSender(auth.NewRepository(db), mailer, authCfg)
+
 	//routes
+	auth.WellKnownRoutes(r, jwtMgr)
 	apiV1 := r.Group("/api/v1")
-	auth.AuthRoutes(apiV1, db, jwtMgr)
+	auth.AuthRoutes(apiV1, db, authn, policy, attempts, mailer, authCfg)
+	rbac.Routes(apiV1, db, authn.Middleware(), policy)
+	admin.Routes(apiV1, authn.Middleware(), policy)
+	doctor.Routes(apiV1, authn.Middleware(), policy)
+	patient.Routes(apiV1, authn.Middleware(), policy, mailer, verification)
+	cid10.Routes(apiV1, authn.Middleware(), cidCatalog)
+	// laudos em PDF: arquivo em EXAM_STORAGE_DIR, texto extraído em Go puro
+	exam.Routes(apiV1, authn.Middleware(),
+		patient.NewAccessGuard(patient.NewRepository(db), policy),
+		exam.NewStorageFromEnv(), exam.NewPDFExtractor())
 
 	//migrations
-	if err := db.AutoMigrate(&user.User{}); err != nil {
+	if err := auth.MigrateUsers(db); err != nil {
 		log.Fatalf("Erro ao migrar tabela users: %v", err)
 	}
+	if err := db.AutoMigrate(
+		&auth.RefreshToken{},
+		&auth.RevokedToken{},
+		&auth.SessionCutoff{},
+		&auth.PasswordResetToken{},
+		&auth.Invitation{},
+		&auth.MFAEnrollment{},
+		&auth.RecoveryCode{},
+		&auth.LoginAttempt{},
+	); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de sessão: %v", err)
+	}
+	if err := professional.Migrate(db); err != nil {
+		log.Fatalf("Erro ao migrar tabela professionals: %v", err)
+	}
+	if err := db.AutoMigrate(&rbac.RoleGrant{}); err != nil {
+		log.Fatalf("Erro ao migrar tabela role_grants: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&patient.PatientProfile{},
+		&patient.MedicalRecord{},
+		&patient.Prevention{},
+		&patient.Problem{},
+		&patient.Exam{},
+		&patient.PhysicalExam{},
+		&patient.Authorization{},
+		&patient.AuthorizationHistory{},
+		&patient.EmergencyAccessLog{},
+	); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de pacientes: %v", err)
+	}
+	if err := db.AutoMigrate(&exam.Exam{}, &exam.AnalitoResult{}, &exam.ValorReferencia{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de exames: %v", err)
+	}
+	if err := rbac.NewRepository(db).SeedDefaults(context.Background()); err != nil {
+		log.Fatalf("Erro ao gravar permissões padrão: %v", err)
+	}
+
+	// encerra autorizações de acesso a pacientes com prazo vencido
+	patientSvc := patient.NewService(patient.NewRepository(db), mailer, verification)
+	go patient.WatchExpirations(context.Background(), patientSvc, time.Minute)
 
 	log.Println("🚀 API running at http://localhost:8080")
 	r.Run(":8080")
@@ -1,20 +1,22 @@
 package admin
 
 import (
-	"sonnda-api/internal/middleware"
+	"sonnda-api/internal/rbac"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Routes(rg *gin.RouterGroup) {
+// Routes registra as rotas do módulo. authenticate é o middleware do
+// auth.Authenticator, compartilhado por todos os grupos protegidos.
+func Routes(rg *gin.RouterGroup, authenticate gin.HandlerFunc, policy *rbac.Policy) {
 	//repo := NewRepository(database.DB)
 	//svc := NewService(repo)
 	//handler := NewHandler(svc)
 
-	// Todas as rotas de admin requerem autenticação e role de admin
+	// Todas as rotas de admin requerem autenticação e a permissão user:admin
 	admin := rg.Group("/admin")
-	admin.Use(middleware.JWTAuthMiddleware())
-	admin.Use(middleware.RequireAdmin())
+	admin.Use(authenticate)
+	admin.Use(policy.Require(rbac.PermUserAdmin))
 	{
 		// Gestão de usuários
 		// GET /api/v1/admin/users
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"sonnda-api/internal/user"
+)
+
+// Config reúne os parâmetros dos fluxos de conta enviados por e-mail.
+type Config struct {
+	AppURL           string        // base dos links enviados ao usuário (front-end)
+	PasswordResetTTL time.Duration // validade do link de redefinição de senha
+	SigningSecret    string        // chave HMAC dos links de verificação e desafios de MFA
+
+	VerificationTTL            time.Duration // validade do link de verificação
+	VerificationResendInterval time.Duration // intervalo mínimo entre reenvios
+	// RequireVerifiedEmail lista as roles que só podem fazer login com e-mail verificado.
+	RequireVerifiedEmail []user.Role
+
+	InvitationTTL time.Duration // validade dos convites emitidos por admins
+
+	MFAIssuer        string        // nome exibido no app autenticador
+	MFAEncryptionKey string        // chave usada para cifrar os segredos TOTP
+	MFAChallengeTTL  time.Duration // validade do desafio entre senha e código TOTP
+	// RequireMFA lista as roles que precisam de segundo fator para concluir o login.
+	RequireMFA []user.Role
+
+	AccountLockout LockoutPolicy // falhas de login por e-mail
+	IPLockout      LockoutPolicy // falhas de login por IP de origem
+}
+
+// minSecretLen é o tamanho mínimo das chaves: com uma chave vazia ou conhecida,
+// qualquer um forja links de verificação e desafios de MFA.
+const minSecretLen = 32
+
+var (
+	ErrWeakSigningSecret = errors.New("TOKEN_SIGNING_SECRET must have at least 32 bytes")
+	ErrWeakMFAKey        = errors.New("MFA_ENCRYPTION_KEY must have at least 32 bytes")
+)
+
+// Validate recusa configurações inseguras; deve ser chamada na subida da API.
+func (c Config) Validate() error {
+	if len(c.SigningSecret) < minSecretLen {
+		return ErrWeakSigningSecret
+	}
+	if len(c.MFAEncryptionKey) < minSecretLen {
+		return ErrWeakMFAKey
+	}
+	return nil
+}
@@ -2,10 +2,13 @@ package auth
 
 import (
 	"errors"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"sonnda-api/internal/middleware"
+	"sonnda-api/internal/professional"
 	"sonnda-api/internal/user"
 
 	"github.com/gin-gonic/gin"
@@ -24,7 +27,7 @@ type registerRequest struct {
 	Name     string    `json:"name" binding:"required,min=2"`
 	Email    string    `json:"email" binding:"required,email"`
 	Password string    `json:"password" binding:"required,min=6"`
-	Role     user.Role `json:"role" binding:"required"` // futura validação: permitir só alguns
+	Role     user.Role `json:"role" binding:"omitempty,eq=PATIENT"` // outras roles só por convite
 }
 
 type loginRequest struct {
@@ -32,6 +35,61 @@ type loginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type verifyMFARequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type enrollMFAChallengeRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+}
+
+type confirmMFARequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type createInvitationRequest struct {
+	Email      string                   `json:"email" binding:"required,email"`
+	Role       user.Role                `json:"role" binding:"required,oneof=DOCTOR ADMIN"`
+	Profession *professional.Profession `json:"profession"`
+}
+
+type unlockRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	IP    string `json:"ip" binding:"omitempty,ip"`
+}
+
+type acceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name" binding:"required,min=2"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
 func (handler *Handler) Register(ctx *gin.Context) {
 	var req registerRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -66,7 +124,7 @@ func (handler *Handler) Register(ctx *gin.Context) {
 		return
 	}
 
-	user, err := handler.svc.Register(ctx, req.Name, req.Email, req.Password, req.Role)
+	user, err := handler.svc.Register(ctx, req.Name, req.Email, req.Password)
 	if err != nil {
 		if err == ErrEmailTaken {
 			ctx.JSON(http.StatusConflict, gin.H{"error": "email_taken"})
@@ -114,33 +172,363 @@ func (handler *Handler) Login(ctx *gin.Context) {
 		return
 	}
 
-	user, token, err := handler.svc.Login(ctx, req.Email, req.Password)
+	res, err := handler.svc.Login(ctx, req.Email, req.Password, ctx.ClientIP())
 	if err != nil {
+		var rle *RateLimitError
+		if errors.As(err, &rle) {
+			writeRateLimited(ctx, rle)
+			return
+		}
 		if err == ErrInvalidCredentials {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_credentials"})
 			return
 		}
+		if err == ErrEmailNotVerified {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "email_not_verified"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
 		return
 	}
 
+	writeLoginResult(ctx, res)
+}
+
+func (handler *Handler) VerifyMFA(ctx *gin.Context) {
+	var req verifyMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	res, err := handler.svc.VerifyMFA(ctx, req.MFAToken, req.Code)
+	if err != nil {
+		var rle *RateLimitError
+		if errors.As(err, &rle) {
+			writeRateLimited(ctx, rle)
+			return
+		}
+		writeMFAError(ctx, err)
+		return
+	}
+	writeLoginResult(ctx, res)
+}
+
+func (handler *Handler) EnrollMFAChallenge(ctx *gin.Context) {
+	var req enrollMFAChallengeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	setup, err := handler.svc.EnrollMFAChallenge(ctx, req.MFAToken)
+	if err != nil {
+		writeMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"secret": setup.Secret, "otpauthUri": setup.URI})
+}
+
+func (handler *Handler) EnrollMFA(ctx *gin.Context) {
+	uid, _ := middleware.GetUserID(ctx)
+	setup, err := handler.svc.EnrollMFA(ctx, uid)
+	if err != nil {
+		writeMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"secret": setup.Secret, "otpauthUri": setup.URI})
+}
+
+func (handler *Handler) ConfirmMFA(ctx *gin.Context) {
+	var req confirmMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	uid, _ := middleware.GetUserID(ctx)
+	codes, err := handler.svc.ConfirmMFA(ctx, uid, req.Code)
+	if err != nil {
+		writeMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+func (handler *Handler) RefreshToken(ctx *gin.Context) {
+	var req refreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tokens, err := handler.svc.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidRefreshToken):
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_refresh_token"})
+		case errors.Is(err, ErrRefreshTokenReused):
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "refresh_token_reused"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		}
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
-		"accessToken": token,
-		"user": gin.H{
-			"id":    user.ID,
-			"email": user.Email,
-			"role":  user.Role,
-		},
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    int(tokens.ExpiresIn.Seconds()),
+	})
+}
+
+func (handler *Handler) Logout(ctx *gin.Context) {
+	p, ok := middleware.CurrentPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// o corpo é opcional: sem refresh token, só o access token é revogado
+	var req logoutRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_body",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := handler.svc.Logout(ctx, p, req.RefreshToken); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (handler *Handler) LogoutAll(ctx *gin.Context) {
+	uid, ok := middleware.GetUserID(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := handler.svc.LogoutAll(ctx, uid); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (handler *Handler) ForgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := handler.svc.ForgotPassword(ctx, req.Email); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	// mesma resposta para e-mails cadastrados ou não
+	ctx.JSON(http.StatusAccepted, gin.H{"status": "reset_email_sent"})
+}
+
+func (handler *Handler) ResetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := handler.svc.ResetPassword(ctx, req.Token, req.Password); err != nil {
+		if errors.Is(err, ErrInvalidResetToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid_reset_token"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (handler *Handler) VerifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := handler.svc.VerifyEmail(ctx, req.Token); err != nil {
+		if errors.Is(err, ErrInvalidVerificationToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid_verification_token"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"status": "email_verified"})
+}
+
+func (handler *Handler) ResendVerification(ctx *gin.Context) {
+	var req resendVerificationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := handler.svc.ResendVerification(ctx, req.Email); err != nil {
+		var rle *RateLimitError
+		if errors.As(err, &rle) {
+			writeRateLimited(ctx, rle)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"status": "verification_email_sent"})
+}
+
+func (handler *Handler) CreateInvitation(ctx *gin.Context) {
+	var req createInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	uid, _ := middleware.GetUserID(ctx)
+	inv, err := handler.svc.CreateInvitation(ctx, uid, req.Email, req.Role, req.Profession)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInviteRole):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid_role"})
+		case errors.Is(err, ErrInvalidProfession):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid_profession"})
+		case errors.Is(err, ErrEmailTaken):
+			ctx.JSON(http.StatusConflict, gin.H{"error": "email_taken"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, inv)
+}
+
+func (handler *Handler) ListInvitations(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+
+	invs, err := handler.svc.ListInvitations(ctx, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, invs)
+}
+
+func (handler *Handler) RevokeInvitation(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id"})
+		return
+	}
+
+	if err := handler.svc.RevokeInvitation(ctx, uint(id)); err != nil {
+		if errors.Is(err, ErrInvalidInvitation) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "invitation_not_found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (handler *Handler) Unlock(ctx *gin.Context) {
+	var req unlockRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := handler.svc.Unlock(ctx, req.Email, req.IP); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (handler *Handler) AcceptInvitation(ctx *gin.Context) {
+	var req acceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	u, err := handler.svc.AcceptInvitation(ctx, req.Token, req.Name, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInvitation):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid_invitation"})
+		case errors.Is(err, ErrEmailTaken):
+			ctx.JSON(http.StatusConflict, gin.H{"error": "email_taken"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":         u.ID,
+		"email":      u.Email,
+		"role":       u.Role,
+		"profession": u.Profession,
 	})
 }
 
 func (handler *Handler) Me(ctx *gin.Context) {
-	uidVal, ok := ctx.Get("userID")
+	uid, ok := middleware.GetUserID(ctx)
 	if !ok {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
-	uid, _ := uidVal.(uint)
 
 	u, err := handler.svc.Me(ctx, uid)
 	if err != nil {
@@ -149,9 +537,64 @@ func (handler *Handler) Me(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"id":    u.ID,
-		"email": u.Email,
-		"role":  u.Role,
+		"id":            u.ID,
+		"email":         u.Email,
+		"role":          u.Role,
+		"emailVerified": u.IsEmailVerified(),
+	})
+}
+
+// writeLoginResult responde com o par de tokens ou, se faltar o segundo
+// fator, com o desafio de MFA.
+func writeLoginResult(ctx *gin.Context, res *LoginResult) {
+	if res.MFA != nil {
+		ctx.JSON(http.StatusOK, gin.H{
+			"mfaRequired":        true,
+			"mfaToken":           res.MFA.Token,
+			"expiresIn":          int(res.MFA.ExpiresIn.Seconds()),
+			"enrollmentRequired": res.MFA.EnrollmentRequired,
+		})
+		return
+	}
+
+	body := gin.H{
+		"accessToken":  res.Tokens.AccessToken,
+		"refreshToken": res.Tokens.RefreshToken,
+		"expiresIn":    int(res.Tokens.ExpiresIn.Seconds()),
+		"user": gin.H{
+			"id":    res.User.ID,
+			"email": res.User.Email,
+			"role":  res.User.Role,
+		},
+	}
+	if len(res.RecoveryCodes) > 0 {
+		body["recoveryCodes"] = res.RecoveryCodes
+	}
+	ctx.JSON(http.StatusOK, body)
+}
+
+func writeMFAError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidMFAChallenge):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_mfa_token"})
+	case errors.Is(err, ErrInvalidMFACode):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_mfa_code"})
+	case errors.Is(err, ErrMFANotEnrolled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "mfa_not_enrolled"})
+	case errors.Is(err, ErrMFAAlreadyEnabled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "mfa_already_enabled"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+	}
+}
+
+// writeRateLimited responde 429 com o cabeçalho Retry-After em segundos.
+func writeRateLimited(ctx *gin.Context, rle *RateLimitError) {
+	secs := int(math.Ceil(rle.RetryAfter.Seconds()))
+	ctx.Header("Retry-After", strconv.Itoa(secs))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{
+		"error":      "too_many_requests",
+		"retryAfter": secs,
 	})
 }
 
@@ -162,16 +605,3 @@ func toJSONField(field string) string {
 	// simples: lowercase primeira letra
 	return strings.ToLower(field[:1]) + field[1:]
 }
-
-// helper opcional se o ID vier como string no contexto:
-func parseUserID(raw any) (uint, bool) {
-	switch v := raw.(type) {
-	case uint:
-		return v, true
-	case string:
-		id, err := strconv.ParseUint(v, 10, 64)
-		return uint(id), err == nil
-	default:
-		return 0, false
-	}
-}
@@ -5,55 +5,77 @@ import (
 	"strconv"
 	"time"
 
+	"sonnda-api/internal/professional"
 	"sonnda-api/internal/user"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type JWTManager struct {
-	Secret []byte
-	Issuer string
-	TTL    time.Duration
+	Keys       *Keyring
+	Issuer     string
+	TTL        time.Duration // validade do access token
+	RefreshTTL time.Duration // validade de cada refresh token
 }
 
 type Claims struct {
-	UserID uint      `json:"uid"`
-	Email  string    `json:"email"`
-	Role   user.Role `json:"role"`
+	UserID     uint                    `json:"uid"`
+	Email      string                  `json:"email"`
+	Role       user.Role               `json:"role"`
+	Profession professional.Profession `json:"prof,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTManager(secret, issuer string, ttl time.Duration) *JWTManager {
+func NewJWTManager(keys *Keyring, issuer string, ttl, refreshTTL time.Duration) *JWTManager {
 	return &JWTManager{
-		Secret: []byte(secret),
-		Issuer: issuer,
-		TTL:    ttl,
+		Keys:       keys,
+		Issuer:     issuer,
+		TTL:        ttl,
+		RefreshTTL: refreshTTL,
 	}
 }
 
 func (j *JWTManager) Generate(u *user.User) (string, error) {
+	jti, err := newRandomID()
+	if err != nil {
+		return "", err
+	}
 	now := time.Now().UTC()
+	key, err := j.Keys.Signer(now)
+	if err != nil {
+		return "", err
+	}
 	claims := &Claims{
 		UserID: u.ID,
 		Email:  u.Email,
 		Role:   u.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			Issuer:    j.Issuer,
 			Subject:   strconv.FormatUint(uint64(u.ID), 10),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(j.TTL)),
 		},
 	}
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString(j.Secret)
+	if u.Profession != nil {
+		claims.Profession = *u.Profession
+	}
+	t := jwt.NewWithClaims(key.Method, claims)
+	t.Header["kid"] = key.ID
+	return t.SignedString(key.signKey)
 }
 
 func (j *JWTManager) Parse(tokenStr string) (*Claims, error) {
 	tok, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		if t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := j.Keys.Verifier(kid, time.Now().UTC())
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		if t.Method.Alg() != key.Method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return j.Secret, nil
+		return key.verifyKey, nil
 	})
 	if err != nil || !tok.Valid {
 		return nil, errors.New("invalid token")
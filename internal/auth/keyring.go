@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrNoSigningKey = errors.New("no active signing key")
+
+// SigningKey é uma chave do keyring, identificada pelo kid do cabeçalho JWT.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	ActiveFrom time.Time // passa a assinar tokens a partir deste instante
+	RetireAt   time.Time // deixa de validar tokens (zero = sem prazo)
+
+	signKey   any // []byte (HMAC), *rsa.PrivateKey ou ed25519.PrivateKey
+	verifyKey any // []byte (HMAC), *rsa.PublicKey ou ed25519.PublicKey
+}
+
+func (k *SigningKey) canVerify(now time.Time) bool {
+	return k.RetireAt.IsZero() || now.Before(k.RetireAt)
+}
+
+// Keyring guarda as chaves de assinatura. A chave ativa é a mais recente cujo
+// ActiveFrom já passou; as anteriores continuam validando tokens até RetireAt,
+// então a rotação é feita publicando uma nova chave com ActiveFrom no futuro.
+type Keyring struct {
+	manifest string // vazio para keyrings em memória (HMAC)
+
+	mu   sync.RWMutex
+	keys []*SigningKey // ordenadas por ActiveFrom
+}
+
+// NewHMACKeyring cria um keyring com uma única chave HS256. Usado em
+// desenvolvimento; chaves simétricas não são publicadas no JWKS.
+func NewHMACKeyring(secret string) *Keyring {
+	return &Keyring{keys: []*SigningKey{{
+		ID:        "hs256",
+		Method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}}}
+}
+
+// keyManifest descreve as chaves em disco. Os arquivos são relativos ao
+// diretório do manifesto.
+//
+//	{"keys": [{"kid": "2025-10", "file": "2025-10.pem", "activeFrom": "2025-10-01T00:00:00Z"}]}
+type keyManifest struct {
+	Keys []struct {
+		KID        string     `json:"kid"`
+		File       string     `json:"file"`
+		ActiveFrom time.Time  `json:"activeFrom"`
+		RetireAt   *time.Time `json:"retireAt,omitempty"`
+	} `json:"keys"`
+}
+
+// LoadKeyring lê o manifesto e as chaves privadas PEM (RSA ou Ed25519).
+func LoadKeyring(manifestPath string) (*Keyring, error) {
+	k := &Keyring{manifest: manifestPath}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload relê o manifesto. Em caso de erro, as chaves atuais são mantidas:
+// um manifesto sem chave que assine agora ou com kid repetido é recusado,
+// para que um arquivo truncado não derrube logins e tokens emitidos.
+func (k *Keyring) Reload() error {
+	if k.manifest == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(k.manifest)
+	if err != nil {
+		return err
+	}
+	var m keyManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("keyring manifest: %w", err)
+	}
+
+	dir := filepath.Dir(k.manifest)
+	keys := make([]*SigningKey, 0, len(m.Keys))
+	seen := make(map[string]bool, len(m.Keys))
+	for _, entry := range m.Keys {
+		if entry.KID == "" {
+			return errors.New("keyring manifest: key without kid")
+		}
+		if seen[entry.KID] {
+			return fmt.Errorf("keyring manifest: duplicated kid %s", entry.KID)
+		}
+		seen[entry.KID] = true
+		key, err := loadPrivateKey(filepath.Join(dir, entry.File))
+		if err != nil {
+			return fmt.Errorf("key %s: %w", entry.KID, err)
+		}
+		key.ID = entry.KID
+		key.ActiveFrom = entry.ActiveFrom
+		if entry.RetireAt != nil {
+			key.RetireAt = *entry.RetireAt
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ActiveFrom.Before(keys[j].ActiveFrom) })
+	if activeKey(keys, time.Now()) == nil {
+		return fmt.Errorf("keyring manifest: %w", ErrNoSigningKey)
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+// Watch recarrega o manifesto periodicamente até ctx ser cancelado, para que
+// novas chaves entrem em rotação sem reiniciar a API.
+func (k *Keyring) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Reload(); err != nil {
+				log.Printf("⚠️  Falha ao recarregar chaves JWT: %v", err)
+			}
+		}
+	}
+}
+
+// Signer retorna a chave que deve assinar tokens no instante informado.
+func (k *Keyring) Signer(now time.Time) (*SigningKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if key := activeKey(k.keys, now); key != nil {
+		return key, nil
+	}
+	return nil, ErrNoSigningKey
+}
+
+// activeKey é a chave mais recente já ativa e não aposentada; keys está
+// ordenado por ActiveFrom.
+func activeKey(keys []*SigningKey, now time.Time) *SigningKey {
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !keys[i].ActiveFrom.After(now) && keys[i].canVerify(now) {
+			return keys[i]
+		}
+	}
+	return nil
+}
+
+// Verifier retorna a chave do kid informado, se ela ainda valida tokens.
+func (k *Keyring) Verifier(kid string, now time.Time) (*SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range k.keys {
+		if key.ID == kid && key.canVerify(now) {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// JWK é uma chave pública no formato da RFC 7517.
+type JWK struct {
+	KTY string `json:"kty"`
+	KID string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+	Crv string `json:"crv,omitempty"` // OKP
+	X   string `json:"x,omitempty"`   // OKP
+}
+
+// JWKS lista as chaves públicas que ainda validam tokens, incluindo as
+// agendadas para o futuro, para que os consumidores já as tenham em cache.
+func (k *Keyring) JWKS(now time.Time) []JWK {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	b64 := base64.RawURLEncoding
+	out := []JWK{}
+	for _, key := range k.keys {
+		if !key.canVerify(now) {
+			continue
+		}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			out = append(out, JWK{
+				KTY: "RSA", KID: key.ID, Use: "sig", Alg: key.Method.Alg(),
+				N: b64.EncodeToString(pub.N.Bytes()),
+				E: b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			out = append(out, JWK{
+				KTY: "OKP", KID: key.ID, Use: "sig", Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   b64.EncodeToString(pub),
+			})
+		}
+	}
+	return out
+}
+
+// loadPrivateKey lê uma chave PEM (PKCS#8 ou PKCS#1) e escolhe o algoritmo
+// pelo tipo: RSA assina com RS256 e Ed25519 com EdDSA.
+func loadPrivateKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+
+	var priv crypto.PrivateKey
+	if priv, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if priv, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, errors.New("unsupported private key format")
+		}
+	}
+
+	switch p := priv.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{Method: jwt.SigningMethodRS256, signKey: p, verifyKey: &p.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{Method: jwt.SigningMethodEdDSA, signKey: p, verifyKey: p.Public()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", priv)
+	}
+}
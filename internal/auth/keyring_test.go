@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKey grava uma chave Ed25519 PKCS#8 em dir/name.
+func writeKey(t *testing.T, dir, name string) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeManifest(t *testing.T, path, manifest string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newKeyDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"old.pem", "current.pem", "next.pem"} {
+		writeKey(t, dir, name)
+	}
+	return dir
+}
+
+func TestKeyringSelectsActiveKey(t *testing.T) {
+	dir := newKeyDir(t)
+	manifest := filepath.Join(dir, "keys.json")
+	writeManifest(t, manifest, `{"keys": [
+		{"kid": "next", "file": "next.pem", "activeFrom": "2999-01-01T00:00:00Z"},
+		{"kid": "old", "file": "old.pem", "activeFrom": "2020-01-01T00:00:00Z", "retireAt": "2999-01-01T00:00:00Z"},
+		{"kid": "current", "file": "current.pem", "activeFrom": "2024-01-01T00:00:00Z"}
+	]}`)
+
+	k, err := LoadKeyring(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	key, err := k.Signer(now)
+	if err != nil || key.ID != "current" {
+		t.Fatalf("signer = %v, %v; want current", key, err)
+	}
+	// a anterior ainda valida tokens até RetireAt; a futura já está no JWKS
+	if _, ok := k.Verifier("old", now); !ok {
+		t.Error("retired-later key no longer verifies")
+	}
+	if len(k.JWKS(now)) != 3 {
+		t.Errorf("JWKS has %d keys, want 3", len(k.JWKS(now)))
+	}
+	// depois da data de ativação, a próxima chave assina
+	if key, _ := k.Signer(time.Date(2999, 6, 1, 0, 0, 0, 0, time.UTC)); key == nil || key.ID != "next" {
+		t.Errorf("signer after rotation = %v, want next", key)
+	}
+}
+
+func TestKeyringRetiredKeyStopsVerifying(t *testing.T) {
+	dir := newKeyDir(t)
+	manifest := filepath.Join(dir, "keys.json")
+	writeManifest(t, manifest, `{"keys": [
+		{"kid": "old", "file": "old.pem", "activeFrom": "2020-01-01T00:00:00Z", "retireAt": "2021-01-01T00:00:00Z"},
+		{"kid": "current", "file": "current.pem", "activeFrom": "2024-01-01T00:00:00Z"}
+	]}`)
+
+	k, err := LoadKeyring(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := k.Verifier("old", time.Now()); ok {
+		t.Error("retired key still verifies")
+	}
+	if _, ok := k.Verifier("old", time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)); !ok {
+		t.Error("key does not verify before its retirement")
+	}
+}
+
+func TestKeyringRejectsManifest(t *testing.T) {
+	cases := []struct {
+		name     string
+		manifest string
+	}{
+		{"vazio", `{}`},
+		{"sem chaves", `{"keys": []}`},
+		{"só chaves futuras", `{"keys": [{"kid": "next", "file": "next.pem", "activeFrom": "2999-01-01T00:00:00Z"}]}`},
+		{"só chaves aposentadas", `{"keys": [{"kid": "old", "file": "old.pem", "activeFrom": "2020-01-01T00:00:00Z", "retireAt": "2021-01-01T00:00:00Z"}]}`},
+		{"kid repetido", `{"keys": [
+			{"kid": "a", "file": "old.pem", "activeFrom": "2020-01-01T00:00:00Z"},
+			{"kid": "a", "file": "current.pem", "activeFrom": "2024-01-01T00:00:00Z"}
+		]}`},
+		{"json truncado", `{"keys": [{"kid": "current", "file": "current.pem", "activeFrom": "2024-01-01T00:00:00Z"`},
+	}
+	for _, tc := range cases {
+		dir := newKeyDir(t)
+		manifest := filepath.Join(dir, "keys.json")
+		writeManifest(t, manifest, tc.manifest)
+		if _, err := LoadKeyring(manifest); err == nil {
+			t.Errorf("%s: manifest accepted", tc.name)
+		}
+	}
+}
+
+func TestKeyringReloadKeepsKeysOnError(t *testing.T) {
+	dir := newKeyDir(t)
+	manifest := filepath.Join(dir, "keys.json")
+	writeManifest(t, manifest, `{"keys": [{"kid": "current", "file": "current.pem", "activeFrom": "2024-01-01T00:00:00Z"}]}`)
+	k, err := LoadKeyring(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeManifest(t, manifest, `{"keys": []}`)
+	if err := k.Reload(); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("reload: got %v, want ErrNoSigningKey", err)
+	}
+	if key, err := k.Signer(time.Now()); err != nil || key.ID != "current" {
+		t.Fatalf("keys replaced by a rejected manifest: %v, %v", key, err)
+	}
+}
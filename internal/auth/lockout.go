@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttempt guarda o contador de falhas de login de uma chave (conta ou IP).
+type LoginAttempt struct {
+	Key           string    `gorm:"primaryKey;size:320"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+}
+
+// lastActivity é o último momento relevante para a janela do contador: a
+// última falha ou o fim do bloqueio, o que vier depois.
+func (a LoginAttempt) lastActivity() time.Time {
+	if a.LockedUntil != nil && a.LockedUntil.After(a.LastFailureAt) {
+		return *a.LockedUntil
+	}
+	return a.LastFailureAt
+}
+
+// AttemptStore guarda os contadores de falhas. A implementação em Postgres
+// permite que várias réplicas da API compartilhem o mesmo estado.
+type AttemptStore interface {
+	// Get retorna o estado atual da chave ou nil se não houver falhas.
+	Get(ctx context.Context, key string) (*LoginAttempt, error)
+	// Fail soma uma falha e retorna o total atualizado. O contador zera se a
+	// última falha (ou o fim do último bloqueio) foi há mais de window.
+	Fail(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// LockoutPolicy define quando e por quanto tempo uma chave é bloqueada.
+type LockoutPolicy struct {
+	MaxFailures int           // falhas toleradas antes do primeiro bloqueio
+	BaseLockout time.Duration // primeiro bloqueio; dobra a cada falha seguinte
+	MaxLockout  time.Duration // teto do bloqueio
+	Window      time.Duration // tempo sem falhas para o contador zerar
+}
+
+// lockoutFor calcula o bloqueio (backoff exponencial) para o total de falhas.
+func (p LockoutPolicy) lockoutFor(failures int) time.Duration {
+	if failures < p.MaxFailures {
+		return 0
+	}
+	d := p.BaseLockout
+	for i := p.MaxFailures; i < failures && d < p.MaxLockout; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxLockout)
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginLimiter aplica as políticas de bloqueio por conta e por IP.
+type loginLimiter struct {
+	store   AttemptStore
+	account LockoutPolicy
+	ip      LockoutPolicy
+}
+
+// check retorna RateLimitError se alguma das chaves estiver bloqueada.
+func (l *loginLimiter) check(ctx context.Context, keys ...string) error {
+	now := time.Now().UTC()
+	var wait time.Duration
+	for _, key := range keys {
+		a, err := l.store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if a != nil && a.LockedUntil != nil && a.LockedUntil.After(now) {
+			wait = max(wait, a.LockedUntil.Sub(now))
+		}
+	}
+	if wait > 0 {
+		return &RateLimitError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// fail registra a falha na chave e aplica o bloqueio da política, se houver.
+func (l *loginLimiter) fail(ctx context.Context, key string, policy LockoutPolicy) error {
+	now := time.Now().UTC()
+	failures, err := l.store.Fail(ctx, key, now, policy.Window)
+	if err != nil {
+		return err
+	}
+	if d := policy.lockoutFor(failures); d > 0 {
+		return l.store.Lock(ctx, key, now.Add(d))
+	}
+	return nil
+}
+
+// failLogin registra uma falha de senha para a conta e para o IP de origem.
+func (l *loginLimiter) failLogin(ctx context.Context, email, ip string) error {
+	if err := l.fail(ctx, accountKey(email), l.account); err != nil {
+		return err
+	}
+	return l.fail(ctx, ipKey(ip), l.ip)
+}
+
+type gormAttemptStore struct {
+	db *gorm.DB
+}
+
+func NewGormAttemptStore(db *gorm.DB) AttemptStore {
+	return &gormAttemptStore{db: db}
+}
+
+func (s *gormAttemptStore) Get(ctx context.Context, key string) (*LoginAttempt, error) {
+	var a LoginAttempt
+	if err := s.db.WithContext(ctx).First(&a, "key = ?", key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Fail faz o incremento num único upsert para não perder falhas concorrentes.
+func (s *gormAttemptStore) Fail(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	var failures int
+	err := s.db.WithContext(ctx).Raw(`
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN GREATEST(login_attempts.last_failure_at, COALESCE(login_attempts.locked_until, login_attempts.last_failure_at)) < ? THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures`,
+		key, now, now.Add(-window),
+	).Scan(&failures).Error
+	return failures, err
+}
+
+func (s *gormAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	return s.db.WithContext(ctx).
+		Model(&LoginAttempt{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+}
+
+func (s *gormAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Delete(&LoginAttempt{}, "key = ?", key).Error
+}
+
+// maxMemoryAttempts limita o crescimento do mapa antes de descartar contadores vencidos.
+const maxMemoryAttempts = 10000
+
+// memoryAttemptStore mantém os contadores no processo. Serve para
+// desenvolvimento e para deploys com uma única instância.
+type memoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]LoginAttempt
+}
+
+func NewMemoryAttemptStore() AttemptStore {
+	return &memoryAttemptStore{attempts: make(map[string]LoginAttempt)}
+}
+
+func (s *memoryAttemptStore) Get(_ context.Context, key string) (*LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+func (s *memoryAttemptStore) Fail(_ context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked(now, window)
+	a, ok := s.attempts[key]
+	if !ok || a.lastActivity().Before(now.Add(-window)) {
+		a = LoginAttempt{Key: key}
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	s.attempts[key] = a
+	return a.Failures, nil
+}
+
+func (s *memoryAttemptStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.attempts[key]; ok {
+		a.LockedUntil = &until
+		s.attempts[key] = a
+	}
+	return nil
+}
+
+func (s *memoryAttemptStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+// sweepLocked descarta contadores vencidos e sem bloqueio ativo quando o mapa
+// fica grande. Deve ser chamada com s.mu travado.
+func (s *memoryAttemptStore) sweepLocked(now time.Time, window time.Duration) {
+	if len(s.attempts) < maxMemoryAttempts {
+		return
+	}
+	for key, a := range s.attempts {
+		if a.lastActivity().Before(now.Add(-window)) {
+			delete(s.attempts, key)
+		}
+	}
+}
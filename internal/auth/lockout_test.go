@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutFor(t *testing.T) {
+	p := LockoutPolicy{MaxFailures: 5, BaseLockout: time.Minute, MaxLockout: time.Hour}
+	cases := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{7, 4 * time.Minute},
+		{10, 32 * time.Minute},
+		{11, time.Hour}, // 64min passa do teto
+		{100, time.Hour},
+	}
+	for _, tc := range cases {
+		if got := p.lockoutFor(tc.failures); got != tc.want {
+			t.Errorf("lockoutFor(%d) = %v, want %v", tc.failures, got, tc.want)
+		}
+	}
+
+	// teto menor que o bloqueio base
+	small := LockoutPolicy{MaxFailures: 1, BaseLockout: time.Hour, MaxLockout: time.Minute}
+	if got := small.lockoutFor(1); got != time.Minute {
+		t.Errorf("capped base lockout = %v, want %v", got, time.Minute)
+	}
+}
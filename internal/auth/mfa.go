@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"strconv"
+	"time"
+
+	"sonnda-api/internal/user"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	purposeMFAChallenge = "mfa-challenge"
+	recoveryCodeCount   = 10
+)
+
+var (
+	ErrMFANotEnrolled      = errors.New("mfa not enrolled")
+	ErrMFAAlreadyEnabled   = errors.New("mfa already enabled")
+	ErrInvalidMFACode      = errors.New("invalid mfa code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired mfa challenge")
+)
+
+// LoginResult é o resultado de uma etapa de login. Quando o usuário precisa
+// de segundo fator, Tokens vem vazio e MFA traz o desafio a ser respondido.
+type LoginResult struct {
+	User          *user.User
+	Tokens        *TokenPair
+	MFA           *MFAChallenge
+	RecoveryCodes []string // só quando o MFA acabou de ser ativado
+}
+
+// MFAChallenge é o token curto entregue após a senha, trocado pelo par de
+// tokens definitivo junto com um código TOTP ou de recuperação.
+type MFAChallenge struct {
+	Token              string
+	ExpiresIn          time.Duration
+	EnrollmentRequired bool // a role exige MFA e o usuário ainda não ativou
+}
+
+// MFASetup traz o que o app autenticador precisa para cadastrar a conta.
+type MFASetup struct {
+	Secret string
+	URI    string
+}
+
+// VerifyMFA conclui o login em duas etapas. Se o usuário estava ativando o MFA
+// durante o login, o código confirma a ativação e os códigos de recuperação
+// são devolvidos uma única vez.
+func (s *service) VerifyMFA(ctx context.Context, challenge, code string) (*LoginResult, error) {
+	u, c, err := s.parseMFAChallenge(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	uid := u.ID
+	// códigos errados contam para o bloqueio da conta, como senhas erradas
+	if err := s.limiter.check(ctx, accountKey(u.Email)); err != nil {
+		return nil, err
+	}
+	e, err := s.repo.FindMFAEnrollment(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrMFANotEnrolled
+	}
+
+	var codes []string
+	if e.ConfirmedAt == nil {
+		codes, err = s.confirmEnrollment(ctx, e, code)
+	} else {
+		err = s.checkSecondFactor(ctx, e, code)
+	}
+	if errors.Is(err, ErrInvalidMFACode) {
+		if ferr := s.limiter.fail(ctx, accountKey(u.Email), s.cfg.AccountLockout); ferr != nil {
+			return nil, ferr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// o desafio vale para um único login
+	if err := s.revocations.Revoke(ctx, c.ID, uid, c.ExpiresAt.Time); err != nil {
+		return nil, err
+	}
+	tokens, err := s.issueTokens(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{User: u, Tokens: tokens, RecoveryCodes: codes}, nil
+}
+
+// EnrollMFAChallenge inicia a ativação do MFA para quem está no meio do login
+// e ainda não tem acesso a um access token.
+func (s *service) EnrollMFAChallenge(ctx context.Context, challenge string) (*MFASetup, error) {
+	u, _, err := s.parseMFAChallenge(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	return s.EnrollMFA(ctx, u.ID)
+}
+
+// EnrollMFA gera um novo segredo TOTP. Enquanto não for confirmado, pode ser
+// gerado de novo (ex.: o usuário perdeu o QR code).
+func (s *service) EnrollMFA(ctx context.Context, userID uint) (*MFASetup, error) {
+	u, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.repo.FindMFAEnrollment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if e != nil && e.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.box.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveMFAEnrollment(ctx, &MFAEnrollment{UserID: userID, SecretSealed: sealed}); err != nil {
+		return nil, err
+	}
+
+	return &MFASetup{
+		Secret: secret,
+		URI:    totpURI(s.cfg.MFAIssuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA ativa o MFA de um usuário já autenticado.
+func (s *service) ConfirmMFA(ctx context.Context, userID uint, code string) ([]string, error) {
+	e, err := s.repo.FindMFAEnrollment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if e.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	return s.confirmEnrollment(ctx, e, code)
+}
+
+// confirmEnrollment valida o primeiro código do app e gera os códigos de
+// recuperação, que são persistidos apenas como hash.
+func (s *service) confirmEnrollment(ctx context.Context, e *MFAEnrollment, code string) ([]string, error) {
+	secret, err := s.box.Open(e.SecretSealed)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := verifyTOTP(secret, code, time.Now(), 0)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		c, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = c
+		records[i] = RecoveryCode{UserID: e.UserID, CodeHash: hashToken(normalizeRecoveryCode(c))}
+	}
+
+	if err := s.repo.ConfirmMFAEnrollment(ctx, e.UserID, step, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkSecondFactor aceita um código TOTP ainda não usado ou um código de
+// recuperação válido.
+func (s *service) checkSecondFactor(ctx context.Context, e *MFAEnrollment, code string) error {
+	secret, err := s.box.Open(e.SecretSealed)
+	if err != nil {
+		return err
+	}
+	if step, ok := verifyTOTP(secret, code, time.Now(), e.LastUsedStep); ok {
+		advanced, err := s.repo.AdvanceMFAStep(ctx, e.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, e.UserID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// newMFAChallenge emite o desafio logo após a senha. Além do usuário, ele
+// leva um jti (usado uma vez só, ver VerifyMFA) e uma impressão do hash da
+// senha: sem passar pela senha não há como montar um desafio válido, e trocar
+// a senha invalida os desafios pendentes.
+func (s *service) newMFAChallenge(u *user.User, enrollmentRequired bool) (*MFAChallenge, error) {
+	jti, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+	uid := strconv.FormatUint(uint64(u.ID), 10)
+	return &MFAChallenge{
+		Token:              s.signer.Sign(purposeMFAChallenge, s.cfg.MFAChallengeTTL, uid, jti, passwordFingerprint(u)),
+		ExpiresIn:          s.cfg.MFAChallengeTTL,
+		EnrollmentRequired: enrollmentRequired,
+	}, nil
+}
+
+// parseMFAChallenge valida o desafio e devolve o usuário e as claims usadas
+// para consultar e registrar o jti na lista de revogados.
+func (s *service) parseMFAChallenge(ctx context.Context, token string) (*user.User, *Claims, error) {
+	fields, exp, err := s.signer.VerifyExpiry(purposeMFAChallenge, token)
+	if err != nil || len(fields) != 3 {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+	uid, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+	u, err := s.repo.FindByID(ctx, uint(uid))
+	if err != nil || !hmac.Equal([]byte(fields[2]), []byte(passwordFingerprint(u))) {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	c := &Claims{UserID: u.ID}
+	c.ID = fields[1]
+	c.ExpiresAt = jwt.NewNumericDate(exp)
+	revoked, err := s.revocations.IsRevoked(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+	return u, c, nil
+}
+
+// passwordFingerprint identifica a senha atual sem expor o hash.
+func passwordFingerprint(u *user.User) string {
+	return hashToken(u.PasswordHash)[:16]
+}
+
+func (s *service) requiresMFA(role user.Role) bool {
+	return containsRole(s.cfg.RequireMFA, role)
+}
@@ -4,10 +4,25 @@ import (
 	"net/http"
 	"strings"
 
+	"sonnda-api/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
-func NewAuthMiddleware(jwt *JWTManager) gin.HandlerFunc {
+// Authenticator é o único ponto de autenticação da API: valida o access token
+// emitido por /login (assinatura, emissor e revogação) e publica o
+// middleware.Principal no contexto da requisição.
+type Authenticator struct {
+	JWT         *JWTManager
+	Revocations RevocationStore
+}
+
+func NewAuthenticator(jwt *JWTManager, revocations RevocationStore) *Authenticator {
+	return &Authenticator{JWT: jwt, Revocations: revocations}
+}
+
+// Middleware deve ser usado por todos os grupos de rotas protegidas.
+func (a *Authenticator) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		h := c.GetHeader("Authorization")
 		if !strings.HasPrefix(h, "Bearer ") {
@@ -16,14 +31,33 @@ func NewAuthMiddleware(jwt *JWTManager) gin.HandlerFunc {
 		}
 		raw := strings.TrimPrefix(h, "Bearer ")
 
-		claims, err := jwt.Parse(raw)
+		claims, err := a.JWT.Parse(raw)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
 			return
 		}
 
-		c.Set("userID", claims.UserID)
-		c.Set("role", claims.Role)
+		revoked, err := a.Revocations.IsRevoked(c, claims)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token_revoked"})
+			return
+		}
+
+		p := &middleware.Principal{
+			UserID:     claims.UserID,
+			Email:      claims.Email,
+			Role:       claims.Role,
+			Profession: claims.Profession,
+			TokenID:    claims.ID,
+		}
+		if claims.ExpiresAt != nil {
+			p.ExpiresAt = claims.ExpiresAt.Time
+		}
+		middleware.SetPrincipal(c, p)
 		c.Next()
 	}
 }
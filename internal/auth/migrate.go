@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"sonnda-api/internal/user"
+
+	"gorm.io/gorm"
+)
+
+// MigrateUsers migra a tabela users. Quando a coluna email_verified_at é
+// criada, as contas que já existiam são marcadas como verificadas: elas são
+// anteriores à verificação de e-mail e, sem isso, médicos e admins ativos
+// ficariam sem login no deploy.
+func MigrateUsers(db *gorm.DB) error {
+	backfill := db.Migrator().HasTable(&user.User{}) &&
+		!db.Migrator().HasColumn(&user.User{}, "EmailVerifiedAt")
+
+	if err := db.AutoMigrate(&user.User{}); err != nil {
+		return err
+	}
+	if !backfill {
+		return nil
+	}
+	return db.Model(&user.User{}).
+		Where("email_verified_at IS NULL").
+		Update("email_verified_at", gorm.Expr("created_at")).Error
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"time"
+
+	"sonnda-api/internal/professional"
+	"sonnda-api/internal/user"
+)
+
+// RefreshToken é um token opaco persistido (apenas o hash) que permite obter
+// novos access tokens. Cada uso gera um novo token na mesma família; reapresentar
+// um token já rotacionado revoga a família inteira.
+type RefreshToken struct {
+	ID         uint       `gorm:"primaryKey"`
+	UserID     uint       `gorm:"not null;index"`
+	FamilyID   string     `gorm:"size:64;not null;index"`
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	RotatedAt  *time.Time // preenchido quando o token é trocado por um novo
+	RevokedAt  *time.Time // preenchido quando a família é revogada
+	ReplacedBy *uint
+	CreatedAt  time.Time
+}
+
+// PasswordResetToken é um token de uso único, com validade curta, enviado por
+// e-mail para redefinição de senha. Apenas o hash é persistido.
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Invitation é um convite emitido por um ADMIN para criar uma conta com role
+// (e opcionalmente profissão) pré-definida. O link enviado por e-mail contém o
+// token; apenas o hash é persistido.
+type Invitation struct {
+	ID         uint                     `gorm:"primaryKey" json:"id"`
+	Email      string                   `gorm:"size:255;not null;index" json:"email"`
+	Role       user.Role                `gorm:"type:varchar(20);not null" json:"role"`
+	Profession *professional.Profession `gorm:"type:varchar(30)" json:"profession,omitempty"`
+	TokenHash  string                   `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	InvitedBy  uint                     `gorm:"not null" json:"invitedBy"`
+	ExpiresAt  time.Time                `gorm:"not null" json:"expiresAt"`
+	AcceptedAt *time.Time               `json:"acceptedAt,omitempty"`
+	AcceptedBy *uint                    `json:"acceptedBy,omitempty"` // usuário criado pelo convite
+	RevokedAt  *time.Time               `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time                `json:"createdAt"`
+}
+
+// MFAEnrollment guarda o segredo TOTP do usuário, cifrado. Só passa a valer
+// após a confirmação com um primeiro código válido.
+type MFAEnrollment struct {
+	UserID       uint   `gorm:"primaryKey"`
+	SecretSealed string `gorm:"size:255;not null"`
+	ConfirmedAt  *time.Time
+	LastUsedStep int64 `gorm:"not null;default:0"` // último passo TOTP aceito (anti-replay)
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// RecoveryCode é um código de uso único para entrar sem o app autenticador.
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"size:64;not null;index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"sonnda-api/internal/user"
 
@@ -12,6 +14,34 @@ type Repository interface {
 	Create(ctx context.Context, u *user.User) error
 	FindByEmail(ctx context.Context, email string) (*user.User, error)
 	FindByID(ctx context.Context, id uint) (*user.User, error)
+	MarkEmailVerified(ctx context.Context, userID uint, at time.Time) error
+	MarkVerificationSent(ctx context.Context, userID uint, at time.Time) error
+
+	// Refresh tokens
+	CreateRefreshToken(ctx context.Context, t *RefreshToken) error
+	FindRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, old, next *RefreshToken) error
+	RevokeRefreshFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uint) error
+
+	// Reset de senha
+	CreatePasswordResetToken(ctx context.Context, t *PasswordResetToken) error
+	FindPasswordResetToken(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+	ResetPassword(ctx context.Context, t *PasswordResetToken, passwordHash string) error
+
+	// Convites
+	CreateInvitation(ctx context.Context, inv *Invitation) error
+	FindInvitation(ctx context.Context, tokenHash string) (*Invitation, error)
+	ListInvitations(ctx context.Context, limit, offset int) ([]Invitation, error)
+	RevokeInvitation(ctx context.Context, id uint) error
+	AcceptInvitation(ctx context.Context, inv *Invitation, u *user.User) error
+
+	// MFA
+	FindMFAEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error)
+	SaveMFAEnrollment(ctx context.Context, e *MFAEnrollment) error
+	ConfirmMFAEnrollment(ctx context.Context, userID uint, step int64, codes []RecoveryCode) error
+	AdvanceMFAStep(ctx context.Context, userID uint, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error)
 }
 
 type repository struct {
@@ -44,3 +74,238 @@ func (r *repository) FindByID(ctx context.Context, id uint) (*user.User, error)
 	}
 	return &u, nil
 }
+
+func (r *repository) MarkEmailVerified(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where("id = ?", userID).
+		Update("email_verified_at", at).Error
+}
+
+func (r *repository) MarkVerificationSent(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&user.User{}).
+		Where("id = ?", userID).
+		Update("email_verification_sent_at", at).Error
+}
+
+func (r *repository) CreateRefreshToken(ctx context.Context, t *RefreshToken) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *repository) FindRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var t RefreshToken
+	if err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RotateRefreshToken marca o token antigo como rotacionado e cria o próximo da
+// família na mesma transação. Se o antigo já tiver sido usado (ex.: duas
+// requisições concorrentes), retorna ErrRefreshTokenReused.
+func (r *repository) RotateRefreshToken(ctx context.Context, old, next *RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", old.ID).
+			Updates(map[string]any{
+				"rotated_at":  time.Now().UTC(),
+				"replaced_by": next.ID,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+		return nil
+	})
+}
+
+// RevokeRefreshFamily revoga todos os tokens ainda ativos de uma família.
+func (r *repository) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).
+		Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now().UTC()).Error
+}
+
+// RevokeUserRefreshTokens revoga todos os refresh tokens ativos do usuário.
+func (r *repository) RevokeUserRefreshTokens(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).
+		Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now().UTC()).Error
+}
+
+func (r *repository) CreatePasswordResetToken(ctx context.Context, t *PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *repository) FindPasswordResetToken(ctx context.Context, tokenHash string) (*PasswordResetToken, error) {
+	var t PasswordResetToken
+	if err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ResetPassword consome o token e grava a nova senha na mesma transação. Os
+// demais tokens pendentes do usuário também são invalidados.
+func (r *repository) ResetPassword(ctx context.Context, t *PasswordResetToken, passwordHash string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		res := tx.Model(&PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", t.ID).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidResetToken
+		}
+		if err := tx.Model(&PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", t.UserID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user.User{}).
+			Where("id = ?", t.UserID).
+			Update("password_hash", passwordHash).Error
+	})
+}
+
+func (r *repository) CreateInvitation(ctx context.Context, inv *Invitation) error {
+	return r.db.WithContext(ctx).Create(inv).Error
+}
+
+func (r *repository) FindInvitation(ctx context.Context, tokenHash string) (*Invitation, error) {
+	var inv Invitation
+	if err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&inv).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// ListInvitations retorna os convites mais recentes primeiro
+func (r *repository) ListInvitations(ctx context.Context, limit, offset int) ([]Invitation, error) {
+	var invs []Invitation
+	err := r.db.WithContext(ctx).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&invs).Error
+	return invs, err
+}
+
+// RevokeInvitation cancela um convite que ainda não foi aceito.
+func (r *repository) RevokeInvitation(ctx context.Context, id uint) error {
+	res := r.db.WithContext(ctx).
+		Model(&Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvalidInvitation
+	}
+	return nil
+}
+
+// AcceptInvitation cria o usuário e marca o convite como aceito na mesma
+// transação, garantindo que cada convite gere uma única conta.
+func (r *repository) AcceptInvitation(ctx context.Context, inv *Invitation, u *user.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", inv.ID).
+			Updates(map[string]any{
+				"accepted_at": time.Now().UTC(),
+				"accepted_by": u.ID,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrInvalidInvitation
+		}
+		return nil
+	})
+}
+
+func (r *repository) FindMFAEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error) {
+	var e MFAEnrollment
+	if err := r.db.WithContext(ctx).First(&e, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *repository) SaveMFAEnrollment(ctx context.Context, e *MFAEnrollment) error {
+	return r.db.WithContext(ctx).Save(e).Error
+}
+
+// ConfirmMFAEnrollment ativa o MFA e substitui os códigos de recuperação.
+func (r *repository) ConfirmMFAEnrollment(ctx context.Context, userID uint, step int64, codes []RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&MFAEnrollment{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userID).
+			Updates(map[string]any{
+				"confirmed_at":   time.Now().UTC(),
+				"last_used_step": step,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrMFAAlreadyEnabled
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// AdvanceMFAStep registra o passo TOTP usado. Retorna false se um passo igual
+// ou posterior já tiver sido aceito (código reaproveitado).
+func (r *repository) AdvanceMFAStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&MFAEnrollment{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+// UseRecoveryCode consome um código de recuperação ainda não usado.
+func (r *repository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now().UTC())
+	return res.RowsAffected == 1, res.Error
+}
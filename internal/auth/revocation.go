@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedToken registra um access token (pelo jti) invalidado antes de expirar.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:64"`
+	UserID    uint      `gorm:"not null;index"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+// SessionCutoff invalida todos os access tokens de um usuário emitidos antes
+// de RevokedBefore ("sair de todos os dispositivos").
+type SessionCutoff struct {
+	UserID        uint      `gorm:"primaryKey"`
+	RevokedBefore time.Time `gorm:"not null"`
+	UpdatedAt     time.Time
+}
+
+// RevocationStore guarda os access tokens revogados e é consultado pelo
+// middleware de autenticação a cada requisição.
+type RevocationStore interface {
+	Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error
+	RevokeAllForUser(ctx context.Context, userID uint, before time.Time) error
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
+}
+
+// NewRevocationStore cria o store persistido no Postgres com cache em memória.
+// cacheTTL limita por quanto tempo uma réplica pode aceitar um token revogado
+// por outra réplica.
+func NewRevocationStore(db *gorm.DB, cacheTTL time.Duration) RevocationStore {
+	return newCachedRevocationStore(&gormRevocationStore{db: db}, cacheTTL)
+}
+
+type gormRevocationStore struct {
+	db *gorm.DB
+}
+
+func (s *gormRevocationStore) Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt}).Error
+}
+
+func (s *gormRevocationStore) RevokeAllForUser(ctx context.Context, userID uint, before time.Time) error {
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "updated_at"}),
+		}).
+		Create(&SessionCutoff{UserID: userID, RevokedBefore: before}).Error
+}
+
+func (s *gormRevocationStore) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	revoked, err := s.isJTIRevoked(ctx, claims.ID)
+	if err != nil || revoked {
+		return revoked, err
+	}
+	cutoff, err := s.cutoff(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	return issuedBefore(claims, cutoff), nil
+}
+
+func (s *gormRevocationStore) isJTIRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := s.db.WithContext(ctx).
+		Model(&RevokedToken{}).
+		Where("jti = ?", jti).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (s *gormRevocationStore) cutoff(ctx context.Context, userID uint) (time.Time, error) {
+	var c SessionCutoff
+	err := s.db.WithContext(ctx).First(&c, "user_id = ?", userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return c.RevokedBefore, nil
+}
+
+// issuedBefore informa se o token foi emitido antes do corte de sessões do
+// usuário. O iat tem precisão de segundos: um token do mesmo segundo do corte
+// pode ter sido emitido antes dele e também é considerado revogado.
+func issuedBefore(claims *Claims, cutoff time.Time) bool {
+	if cutoff.IsZero() || claims.IssuedAt == nil {
+		return false
+	}
+	return !claims.IssuedAt.Time.After(cutoff.Truncate(time.Second))
+}
+
+// cachedRevocationStore mantém em memória o resultado das consultas ao store
+// persistido. Revogações feitas nesta réplica entram no cache imediatamente.
+type cachedRevocationStore struct {
+	next *gormRevocationStore
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	jtis    map[string]cacheEntry[bool]
+	cutoffs map[uint]cacheEntry[time.Time]
+}
+
+// maxCachedJTIs limita o crescimento do cache antes de descartar entradas vencidas.
+const maxCachedJTIs = 10000
+
+type cacheEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+func newCachedRevocationStore(next *gormRevocationStore, ttl time.Duration) *cachedRevocationStore {
+	return &cachedRevocationStore{
+		next:    next,
+		ttl:     ttl,
+		jtis:    make(map[string]cacheEntry[bool]),
+		cutoffs: make(map[uint]cacheEntry[time.Time]),
+	}
+}
+
+func (s *cachedRevocationStore) Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	if err := s.next.Revoke(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	// um jti revogado nunca volta a valer: mantém até o token expirar
+	s.jtis[jti] = cacheEntry[bool]{value: true, expiresAt: expiresAt}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *cachedRevocationStore) RevokeAllForUser(ctx context.Context, userID uint, before time.Time) error {
+	if err := s.next.RevokeAllForUser(ctx, userID, before); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cutoffs[userID] = cacheEntry[time.Time]{value: before, expiresAt: time.Now().Add(s.ttl)}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *cachedRevocationStore) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	jtiEntry, jtiCached := s.jtis[claims.ID]
+	cutoffEntry, cutoffCached := s.cutoffs[claims.UserID]
+	s.mu.Unlock()
+
+	if !jtiCached || now.After(jtiEntry.expiresAt) {
+		revoked, err := s.next.isJTIRevoked(ctx, claims.ID)
+		if err != nil {
+			return false, err
+		}
+		jtiEntry = cacheEntry[bool]{value: revoked, expiresAt: now.Add(s.ttl)}
+		if revoked && claims.ExpiresAt != nil {
+			jtiEntry.expiresAt = claims.ExpiresAt.Time
+		}
+		s.mu.Lock()
+		s.jtis[claims.ID] = jtiEntry
+		s.sweepLocked(now)
+		s.mu.Unlock()
+	}
+	if jtiEntry.value {
+		return true, nil
+	}
+
+	if !cutoffCached || now.After(cutoffEntry.expiresAt) {
+		cutoff, err := s.next.cutoff(ctx, claims.UserID)
+		if err != nil {
+			return false, err
+		}
+		cutoffEntry = cacheEntry[time.Time]{value: cutoff, expiresAt: now.Add(s.ttl)}
+		s.mu.Lock()
+		s.cutoffs[claims.UserID] = cutoffEntry
+		s.mu.Unlock()
+	}
+	return issuedBefore(claims, cutoffEntry.value), nil
+}
+
+// sweepLocked remove entradas vencidas quando o cache de jtis fica grande.
+// Deve ser chamada com s.mu travado.
+func (s *cachedRevocationStore) sweepLocked(now time.Time) {
+	if len(s.jtis) < maxCachedJTIs {
+		return
+	}
+	for jti, e := range s.jtis {
+		if now.After(e.expiresAt) {
+			delete(s.jtis, jti)
+		}
+	}
+	for uid, e := range s.cutoffs {
+		if now.After(e.expiresAt) {
+			delete(s.cutoffs, uid)
+		}
+	}
+}
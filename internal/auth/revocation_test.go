@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestIssuedBefore(t *testing.T) {
+	cutoff := time.Date(2025, 3, 10, 14, 0, 5, 700_000_000, time.UTC)
+	cases := []struct {
+		name string
+		iat  *jwt.NumericDate
+		cut  time.Time
+		want bool
+	}{
+		{"sem corte", jwt.NewNumericDate(cutoff.Add(-time.Hour)), time.Time{}, false},
+		{"sem iat", nil, cutoff, false},
+		{"antes do corte", jwt.NewNumericDate(cutoff.Add(-2 * time.Second)), cutoff, true},
+		// emitido às 14:00:05.2, antes do corte às 14:00:05.7: o iat só guarda 14:00:05
+		{"mesmo segundo", jwt.NewNumericDate(cutoff.Add(-500 * time.Millisecond)), cutoff, true},
+		{"corte exato", jwt.NewNumericDate(cutoff.Truncate(time.Second)), cutoff.Truncate(time.Second), true},
+		{"depois do corte", jwt.NewNumericDate(cutoff.Add(time.Second)), cutoff, false},
+	}
+	for _, tc := range cases {
+		c := &Claims{UserID: 1}
+		c.IssuedAt = tc.iat
+		if got := issuedBefore(c, tc.cut); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -1,40 +1,98 @@
 package auth
 
 import (
+	"net/http"
+	"time"
+
+	"sonnda-api/internal/mail"
+	"sonnda-api/internal/rbac"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func AuthRoutes(rg *gin.RouterGroup, db *gorm.DB, jwt *JWTManager) {
+func AuthRoutes(rg *gin.RouterGroup, db *gorm.DB, authn *Authenticator, policy *rbac.Policy, attempts AttemptStore, mailer mail.Mailer, cfg Config) {
 	repo := NewRepository(db)
-	svc := NewService(repo, jwt)
+	svc := NewService(repo, authn.JWT, authn.Revocations, mailer, attempts, cfg)
 	h := NewHandler(svc)
 
 	// Rotas públicas de autenticação
 	// POST /api/v1/auth/login
 	rg.POST("/login", h.Login)
 
+	// POST /api/v1/auth/login/mfa
+	rg.POST("/login/mfa", h.VerifyMFA)
+
+	// POST /api/v1/auth/login/mfa/enroll
+	rg.POST("/login/mfa/enroll", h.EnrollMFAChallenge)
+
 	// POST /api/v1/auth/register
 	rg.POST("/register", h.Register)
 
 	// POST /api/v1/auth/refresh
-	//auth.POST("/refresh", h.RefreshToken)
-
-	// POST /api/v1/auth/logout
-	//auth.POST("/logout", h.Logout)
+	rg.POST("/refresh", h.RefreshToken)
 
 	// POST /api/v1/auth/forgot-password
-	//auth.POST("/forgot-password", h.ForgotPassword)
+	rg.POST("/forgot-password", h.ForgotPassword)
 
 	// POST /api/v1/auth/reset-password
-	//auth.POST("/reset-password", h.ResetPassword)
+	rg.POST("/reset-password", h.ResetPassword)
+
+	// POST /api/v1/auth/invitations/accept
+	rg.POST("/invitations/accept", h.AcceptInvitation)
+
+	// POST /api/v1/auth/verify-email
+	rg.POST("/verify-email", h.VerifyEmail)
+
+	// POST /api/v1/auth/resend-verification
+	rg.POST("/resend-verification", h.ResendVerification)
 
 	// Rotas protegidas - requerem autenticação
 
 	protected := rg.Group("")
-	protected.Use(NewAuthMiddleware(jwt))
+	protected.Use(authn.Middleware())
 	{
 		protected.GET("/me", h.Me)
+
+		// POST /api/v1/auth/logout
+		protected.POST("/logout", h.Logout)
+
+		// POST /api/v1/auth/logout-all
+		protected.POST("/logout-all", h.LogoutAll)
+
+		// POST /api/v1/auth/mfa/enroll
+		protected.POST("/mfa/enroll", h.EnrollMFA)
+
+		// POST /api/v1/auth/mfa/confirm
+		protected.POST("/mfa/confirm", h.ConfirmMFA)
+	}
+
+	// Convites - requer user:admin
+	invitations := protected.Group("/invitations")
+	invitations.Use(policy.Require(rbac.PermUserAdmin))
+	{
+		// POST /api/v1/auth/invitations
+		invitations.POST("", h.CreateInvitation)
+
+		// GET /api/v1/auth/invitations
+		invitations.GET("", h.ListInvitations)
+
+		// DELETE /api/v1/auth/invitations/:id
+		invitations.DELETE("/:id", h.RevokeInvitation)
 	}
 
+	// Desbloqueio de login - requer user:admin
+	// POST /api/v1/auth/lockouts/unlock
+	protected.POST("/lockouts/unlock", policy.Require(rbac.PermUserAdmin), h.Unlock)
+
+}
+
+// WellKnownRoutes publica as chaves públicas de verificação em
+// /.well-known/jwks.json, para serviços que validam tokens da Sonnda sem
+// conhecer a chave de assinatura.
+func WellKnownRoutes(r gin.IRoutes, jwt *JWTManager) {
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, gin.H{"keys": jwt.Keys.JWKS(time.Now().UTC())})
+	})
 }
@@ -3,33 +3,114 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
 
+	"sonnda-api/internal/mail"
+	"sonnda-api/internal/middleware"
+	"sonnda-api/internal/professional"
 	"sonnda-api/internal/user"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrEmailTaken         = errors.New("email already registered")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrEmailTaken          = errors.New("email already registered")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrInvalidResetToken   = errors.New("invalid or expired reset token")
+	ErrEmailNotVerified    = errors.New("email not verified")
+
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+	ErrInvalidInviteRole = errors.New("role not allowed for invitation")
+	ErrInvalidProfession = errors.New("invalid profession")
 )
 
+// invitableRoles são as roles que só podem ser obtidas por convite.
+var invitableRoles = []user.Role{user.RoleDoctor, user.RoleAdmin}
+
+// RateLimitError indica que a operação foi bloqueada temporariamente e pode
+// ser tentada de novo depois de RetryAfter.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("too many requests, retry after %s", e.RetryAfter)
+}
+
+// TokenPair é o par de tokens entregue ao cliente no login e no refresh.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
 type Service interface {
-	Register(ctx context.Context, name, email, password string, role user.Role) (*user.User, error)
-	Login(ctx context.Context, email, password string) (*user.User, string, error)
+	Register(ctx context.Context, name, email, password string) (*user.User, error)
+	Login(ctx context.Context, email, password, ip string) (*LoginResult, error)
+	VerifyMFA(ctx context.Context, challenge, code string) (*LoginResult, error)
+	EnrollMFAChallenge(ctx context.Context, challenge string) (*MFASetup, error)
+	EnrollMFA(ctx context.Context, userID uint) (*MFASetup, error)
+	ConfirmMFA(ctx context.Context, userID uint, code string) ([]string, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, p *middleware.Principal, refreshToken string) error
+	LogoutAll(ctx context.Context, userID uint) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	CreateInvitation(ctx context.Context, invitedBy uint, email string, role user.Role, profession *professional.Profession) (*Invitation, error)
+	ListInvitations(ctx context.Context, limit, offset int) ([]Invitation, error)
+	Unlock(ctx context.Context, email, ip string) error
+	RevokeInvitation(ctx context.Context, id uint) error
+	AcceptInvitation(ctx context.Context, token, name, password string) (*user.User, error)
 	Me(ctx context.Context, id uint) (*user.User, error)
 }
 
 type service struct {
-	repo Repository
-	jwt  *JWTManager
+	repo         Repository
+	jwt          *JWTManager
+	revocations  RevocationStore
+	mailer       mail.Mailer
+	signer       hmacSigner
+	verifier     *emailVerifier
+	verification *VerificationSender
+	box          *secretBox
+	limiter      *loginLimiter
+	cfg          Config
 }
 
-func NewService(repo Repository, jwt *JWTManager) Service {
-	return &service{repo: repo, jwt: jwt}
+// NewService falha (panic) com chaves fracas: main valida a configuração antes
+// com Config.Validate, então isso só acontece por erro de programação.
+func NewService(repo Repository, jwt *JWTManager, revocations RevocationStore, mailer mail.Mailer, attempts AttemptStore, cfg Config) Service {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+	signer := hmacSigner{secret: []byte(cfg.SigningSecret)}
+	verifier := newEmailVerifier(signer, cfg.VerificationTTL)
+	return &service{
+		repo:         repo,
+		jwt:          jwt,
+		revocations:  revocations,
+		mailer:       mailer,
+		signer:       signer,
+		verifier:     verifier,
+		verification: &VerificationSender{repo: repo, mailer: mailer, verifier: verifier, appURL: cfg.AppURL},
+		box:          newSecretBox(cfg.MFAEncryptionKey),
+		limiter:      &loginLimiter{store: attempts, account: cfg.AccountLockout, ip: cfg.IPLockout},
+		cfg:          cfg,
+	}
 }
 
-func (s *service) Register(ctx context.Context, name, email, password string, role user.Role) (*user.User, error) {
+// Register cria uma conta pelo cadastro público, sempre com role PATIENT.
+// Médicos e admins entram apenas por convite (ver AcceptInvitation).
+func (s *service) Register(ctx context.Context, name, email, password string) (*user.User, error) {
 	if existing, _ := s.repo.FindByEmail(ctx, email); existing != nil {
 		return nil, ErrEmailTaken
 	}
@@ -42,31 +123,390 @@ func (s *service) Register(ctx context.Context, name, email, password string, ro
 	u := &user.User{
 		Email:        email,
 		PasswordHash: string(hash),
-		Role:         role,
+		Role:         user.RolePatient,
 	}
 
 	if err := s.repo.Create(ctx, u); err != nil {
 		return nil, err
 	}
+
+	// falha no envio não impede o cadastro: o usuário pode pedir reenvio
+	if err := s.verification.Send(ctx, u); err != nil {
+		log.Printf("⚠️  Falha ao enviar verificação de e-mail para usuário %d: %v", u.ID, err)
+	}
 	return u, nil
 }
 
-func (s *service) Login(ctx context.Context, email, password string) (*user.User, string, error) {
+// Login valida a senha. Se o usuário tiver MFA ativo (ou a role exigir MFA),
+// devolve apenas um desafio; os tokens saem em VerifyMFA. Falhas contam para
+// o bloqueio da conta e do IP, inclusive para e-mails inexistentes.
+func (s *service) Login(ctx context.Context, email, password, ip string) (*LoginResult, error) {
+	if err := s.limiter.check(ctx, accountKey(email), ipKey(ip)); err != nil {
+		return nil, err
+	}
+
 	u, err := s.repo.FindByEmail(ctx, email)
 	if err != nil {
-		return nil, "", ErrInvalidCredentials
+		return nil, s.loginFailed(ctx, email, ip)
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
-		return nil, "", ErrInvalidCredentials
+		return nil, s.loginFailed(ctx, email, ip)
 	}
 
-	token, err := s.jwt.Generate(u)
+	// o contador do IP não é zerado: uma conta válida não pode "limpar" o IP
+	if err := s.limiter.store.Reset(ctx, accountKey(email)); err != nil {
+		return nil, err
+	}
+
+	if !u.IsEmailVerified() && s.requiresVerifiedEmail(u.Role) {
+		return nil, ErrEmailNotVerified
+	}
+
+	e, err := s.repo.FindMFAEnrollment(ctx, u.ID)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+	enabled := e != nil && e.ConfirmedAt != nil
+	if enabled || s.requiresMFA(u.Role) {
+		challenge, err := s.newMFAChallenge(u, !enabled)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: u, MFA: challenge}, nil
+	}
+
+	tokens, err := s.issueTokens(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{User: u, Tokens: tokens}, nil
+}
+
+// loginFailed registra a falha e devolve o erro a ser retornado ao cliente.
+func (s *service) loginFailed(ctx context.Context, email, ip string) error {
+	if err := s.limiter.failLogin(ctx, email, ip); err != nil {
+		return err
+	}
+	return ErrInvalidCredentials
+}
+
+// Unlock remove o bloqueio de uma conta e, se informado, de um IP.
+func (s *service) Unlock(ctx context.Context, email, ip string) error {
+	if err := s.limiter.store.Reset(ctx, accountKey(email)); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return s.limiter.store.Reset(ctx, ipKey(ip))
+}
+
+// issueTokens abre uma nova sessão (família de refresh tokens) para o usuário.
+func (s *service) issueTokens(ctx context.Context, u *user.User) (*TokenPair, error) {
+	familyID, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, rt, err := s.newRefreshToken(u.ID, familyID)
+	if err != nil {
+		return nil, err
 	}
+	if err := s.repo.CreateRefreshToken(ctx, rt); err != nil {
+		return nil, err
+	}
+
+	access, err := s.jwt.Generate(u)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{AccessToken: access, RefreshToken: raw, ExpiresIn: s.jwt.TTL}, nil
+}
+
+// Refresh troca um refresh token válido por um novo par de tokens. O token
+// apresentado deixa de valer; se ele for reapresentado depois, toda a família
+// é revogada e o usuário precisa autenticar novamente.
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	old, err := s.repo.FindRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if old == nil || old.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if old.RotatedAt != nil {
+		if err := s.repo.RevokeRefreshFamily(ctx, old.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if time.Now().UTC().After(old.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	u, err := s.repo.FindByID(ctx, old.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	raw, next, err := s.newRefreshToken(u.ID, old.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.RotateRefreshToken(ctx, old, next); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			if err := s.repo.RevokeRefreshFamily(ctx, old.FamilyID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	access, err := s.jwt.Generate(u)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{AccessToken: access, RefreshToken: raw, ExpiresIn: s.jwt.TTL}, nil
+}
+
+// Logout encerra a sessão atual: revoga o access token apresentado e, se
+// informado, a família do refresh token correspondente.
+func (s *service) Logout(ctx context.Context, p *middleware.Principal, refreshToken string) error {
+	expiresAt := p.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().UTC().Add(s.jwt.TTL)
+	}
+	if err := s.revocations.Revoke(ctx, p.TokenID, p.UserID, expiresAt); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		return nil
+	}
+	rt, err := s.repo.FindRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if rt == nil || rt.UserID != p.UserID {
+		return nil
+	}
+	return s.repo.RevokeRefreshFamily(ctx, rt.FamilyID)
+}
+
+// LogoutAll encerra todas as sessões do usuário em todos os dispositivos.
+func (s *service) LogoutAll(ctx context.Context, userID uint) error {
+	if err := s.revocations.RevokeAllForUser(ctx, userID, time.Now().UTC()); err != nil {
+		return err
+	}
+	return s.repo.RevokeUserRefreshTokens(ctx, userID)
+}
 
-	return u, token, nil
+// ForgotPassword envia um link de redefinição de senha. Para não revelar quais
+// e-mails estão cadastrados, e-mails desconhecidos não geram erro.
+func (s *service) ForgotPassword(ctx context.Context, email string) error {
+	u, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	t := &PasswordResetToken{
+		UserID:    u.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().UTC().Add(s.cfg.PasswordResetTTL),
+	}
+	if err := s.repo.CreatePasswordResetToken(ctx, t); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.cfg.AppURL, url.QueryEscape(raw))
+	msg := mail.Message{
+		To:      u.Email,
+		Subject: "Sonnda - Redefinição de senha",
+		Body: fmt.Sprintf("Recebemos um pedido para redefinir sua senha.\n\n"+
+			"Acesse o link abaixo em até %d minutos:\n%s\n\n"+
+			"Se você não fez esse pedido, ignore este e-mail.",
+			int(s.cfg.PasswordResetTTL.Minutes()), link),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("⚠️  Falha ao enviar e-mail de reset para usuário %d: %v", u.ID, err)
+		return err
+	}
+	return nil
+}
+
+// ResetPassword troca a senha usando um token de reset válido e encerra todas
+// as sessões abertas do usuário.
+func (s *service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	t, err := s.repo.FindPasswordResetToken(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	if t == nil || t.UsedAt != nil || time.Now().UTC().After(t.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.ResetPassword(ctx, t, string(hash)); err != nil {
+		return err
+	}
+
+	return s.LogoutAll(ctx, t.UserID)
+}
+
+// VerifyEmail confirma o e-mail do usuário a partir do link enviado.
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	uid, email, err := s.verifier.Parse(token)
+	if err != nil {
+		return err
+	}
+
+	u, err := s.repo.FindByID(ctx, uid)
+	if err != nil || u.Email != email {
+		return ErrInvalidVerificationToken
+	}
+	if u.IsEmailVerified() {
+		return nil
+	}
+	return s.repo.MarkEmailVerified(ctx, u.ID, time.Now().UTC())
+}
+
+// ResendVerification reenvia o link de verificação, respeitando o intervalo
+// mínimo entre envios. E-mails desconhecidos ou já verificados não geram erro.
+func (s *service) ResendVerification(ctx context.Context, email string) error {
+	u, err := s.repo.FindByEmail(ctx, email)
+	if err != nil || u.IsEmailVerified() {
+		return nil
+	}
+
+	if u.EmailVerificationSentAt != nil {
+		next := u.EmailVerificationSentAt.Add(s.cfg.VerificationResendInterval)
+		if wait := time.Until(next); wait > 0 {
+			return &RateLimitError{RetryAfter: wait}
+		}
+	}
+	return s.verification.Send(ctx, u)
+}
+
+// CreateInvitation emite um convite para DOCTOR ou ADMIN e envia o link por e-mail.
+func (s *service) CreateInvitation(ctx context.Context, invitedBy uint, email string, role user.Role, profession *professional.Profession) (*Invitation, error) {
+	if !containsRole(invitableRoles, role) {
+		return nil, ErrInvalidInviteRole
+	}
+	if profession != nil && !profession.Valid() {
+		return nil, ErrInvalidProfession
+	}
+	if existing, _ := s.repo.FindByEmail(ctx, email); existing != nil {
+		return nil, ErrEmailTaken
+	}
+
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	inv := &Invitation{
+		Email:      email,
+		Role:       role,
+		Profession: profession,
+		TokenHash:  hash,
+		InvitedBy:  invitedBy,
+		ExpiresAt:  time.Now().UTC().Add(s.cfg.InvitationTTL),
+	}
+	if err := s.repo.CreateInvitation(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	link := fmt.Sprintf("%s/accept-invite?token=%s", s.cfg.AppURL, url.QueryEscape(raw))
+	msg := mail.Message{
+		To:      email,
+		Subject: "Sonnda - Convite de acesso",
+		Body: fmt.Sprintf("Você foi convidado(a) para acessar a Sonnda.\n\n"+
+			"Crie sua conta pelo link abaixo até %s:\n%s",
+			inv.ExpiresAt.Format("02/01/2006 15:04"), link),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("⚠️  Falha ao enviar convite %d: %v", inv.ID, err)
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (s *service) ListInvitations(ctx context.Context, limit, offset int) ([]Invitation, error) {
+	return s.repo.ListInvitations(ctx, limit, offset)
+}
+
+func (s *service) RevokeInvitation(ctx context.Context, id uint) error {
+	return s.repo.RevokeInvitation(ctx, id)
+}
+
+// AcceptInvitation cria a conta com a role do convite. O e-mail é considerado
+// verificado, já que o token só chega por ele.
+func (s *service) AcceptInvitation(ctx context.Context, token, name, password string) (*user.User, error) {
+	inv, err := s.repo.FindInvitation(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil || inv.AcceptedAt != nil || inv.RevokedAt != nil || time.Now().UTC().After(inv.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+	if existing, _ := s.repo.FindByEmail(ctx, inv.Email); existing != nil {
+		return nil, ErrEmailTaken
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	u := &user.User{
+		Email:           inv.Email,
+		PasswordHash:    string(hash),
+		Role:            inv.Role,
+		Profession:      inv.Profession,
+		EmailVerifiedAt: &now,
+	}
+	if err := s.repo.AcceptInvitation(ctx, inv, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *service) requiresVerifiedEmail(role user.Role) bool {
+	return containsRole(s.cfg.RequireVerifiedEmail, role)
+}
+
+func containsRole(roles []user.Role, role user.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// newRefreshToken gera um refresh token para a família informada. Retorna o
+// valor a ser entregue ao cliente e o registro (com hash) a ser persistido.
+func (s *service) newRefreshToken(userID uint, familyID string) (string, *RefreshToken, error) {
+	raw, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	return raw, &RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().UTC().Add(s.jwt.RefreshTTL),
+	}, nil
 }
 
 func (s *service) Me(ctx context.Context, id uint) (*user.User, error) {
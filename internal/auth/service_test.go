@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sonnda-api/internal/user"
+)
+
+// fakeRepo implementa só o que Refresh usa; os demais métodos do Repository
+// embutido causam panic se forem chamados.
+type fakeRepo struct {
+	Repository
+	tokens    map[string]*RefreshToken
+	rotateErr error
+	revoked   []string
+}
+
+func (r *fakeRepo) FindRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	return r.tokens[tokenHash], nil
+}
+
+func (r *fakeRepo) RotateRefreshToken(ctx context.Context, old, next *RefreshToken) error {
+	if r.rotateErr != nil {
+		return r.rotateErr
+	}
+	now := time.Now().UTC()
+	old.RotatedAt = &now
+	r.tokens[next.TokenHash] = next
+	return nil
+}
+
+func (r *fakeRepo) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	r.revoked = append(r.revoked, familyID)
+	return nil
+}
+
+func (r *fakeRepo) FindByID(ctx context.Context, id uint) (*user.User, error) {
+	return &user.User{ID: id, Email: "paciente@example.com", Role: user.RolePatient}, nil
+}
+
+func newRefreshTestService(t *testing.T) (*service, *fakeRepo, string) {
+	t.Helper()
+	repo := &fakeRepo{tokens: make(map[string]*RefreshToken)}
+	s := &service{
+		repo: repo,
+		jwt:  NewJWTManager(NewHMACKeyring("segredo-de-teste"), "test", time.Minute, time.Hour),
+	}
+	raw, rt, err := s.newRefreshToken(1, "familia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.tokens[rt.TokenHash] = rt
+	return s, repo, raw
+}
+
+func TestRefreshRotates(t *testing.T) {
+	s, repo, raw := newRefreshTestService(t)
+
+	pair, err := s.Refresh(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.RefreshToken == raw || pair.AccessToken == "" {
+		t.Fatalf("expected a new token pair, got %+v", pair)
+	}
+	if len(repo.revoked) != 0 {
+		t.Fatalf("family revoked on a normal rotation: %v", repo.revoked)
+	}
+	if _, err := s.Refresh(context.Background(), pair.RefreshToken); err != nil {
+		t.Fatalf("rotated token rejected: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	s, repo, raw := newRefreshTestService(t)
+
+	if _, err := s.Refresh(context.Background(), raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Refresh(context.Background(), raw); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("got %v, want ErrRefreshTokenReused", err)
+	}
+	if len(repo.revoked) != 1 || repo.revoked[0] != "familia" {
+		t.Fatalf("family not revoked: %v", repo.revoked)
+	}
+}
+
+func TestRefreshConcurrentRotationRevokesFamily(t *testing.T) {
+	s, repo, raw := newRefreshTestService(t)
+	// outra requisição trocou o token entre a busca e a rotação
+	repo.rotateErr = ErrRefreshTokenReused
+
+	if _, err := s.Refresh(context.Background(), raw); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("got %v, want ErrRefreshTokenReused", err)
+	}
+	if len(repo.revoked) != 1 {
+		t.Fatalf("family not revoked: %v", repo.revoked)
+	}
+}
+
+func TestRefreshRejectsRevokedAndExpired(t *testing.T) {
+	s, repo, raw := newRefreshTestService(t)
+	rt := repo.tokens[hashToken(raw)]
+
+	now := time.Now().UTC()
+	rt.RevokedAt = &now
+	if _, err := s.Refresh(context.Background(), raw); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("revoked: got %v, want ErrInvalidRefreshToken", err)
+	}
+
+	rt.RevokedAt = nil
+	rt.ExpiresAt = now.Add(-time.Minute)
+	if _, err := s.Refresh(context.Background(), raw); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expired: got %v, want ErrInvalidRefreshToken", err)
+	}
+
+	if _, err := s.Refresh(context.Background(), "desconhecido"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("unknown: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errInvalidSignedToken = errors.New("invalid signed token")
+
+// newOpaqueToken gera um token aleatório (enviado ao cliente) e o hash que
+// deve ser persistido no banco.
+func newOpaqueToken() (raw, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	raw = hex.EncodeToString(b)
+	return raw, hashToken(raw), nil
+}
+
+// hashToken retorna o SHA-256 (hex) de um token opaco.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRandomID gera um identificador aleatório (jti, família de refresh tokens).
+func newRandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hmacSigner assina tokens curtos e sem estado (links de e-mail, desafios de
+// MFA). O propósito entra na assinatura, então um token emitido para um fluxo
+// não é aceito em outro.
+type hmacSigner struct {
+	secret []byte
+}
+
+// Sign codifica os campos e a validade e devolve "payload.assinatura".
+func (s hmacSigner) Sign(purpose string, ttl time.Duration, fields ...string) string {
+	exp := strconv.FormatInt(time.Now().UTC().Add(ttl).Unix(), 10)
+	payload := strings.Join(append(fields, exp), "|")
+	enc := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return enc + "." + s.mac(purpose, enc)
+}
+
+// Verify confere assinatura e validade e devolve os campos originais.
+func (s hmacSigner) Verify(purpose, token string) ([]string, error) {
+	fields, _, err := s.VerifyExpiry(purpose, token)
+	return fields, err
+}
+
+// VerifyExpiry é Verify devolvendo também a validade do token.
+func (s hmacSigner) VerifyExpiry(purpose, token string) ([]string, time.Time, error) {
+	enc, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.mac(purpose, enc))) {
+		return nil, time.Time{}, errInvalidSignedToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		return nil, time.Time{}, errInvalidSignedToken
+	}
+	parts := strings.Split(string(raw), "|")
+	exp, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil || time.Now().UTC().Unix() > exp {
+		return nil, time.Time{}, errInvalidSignedToken
+	}
+	return parts[:len(parts)-1], time.Unix(exp, 0).UTC(), nil
+}
+
+func (s hmacSigner) mac(purpose, enc string) string {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte(purpose + "|" + enc))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
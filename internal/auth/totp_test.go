@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// segredo do RFC 6238 ("12345678901234567890") em base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	key, err := b32.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// T = 59s: 94287082 com 8 dígitos
+	if got := totpCode(key, 59/30); got != "287082" {
+		t.Fatalf("got %s, want 287082", got)
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	key, _ := b32.DecodeString(rfcSecret)
+	now := time.Unix(1_700_000_000, 0)
+	current := now.Unix() / 30
+	code := func(step int64) string { return totpCode(key, step) }
+
+	cases := []struct {
+		name     string
+		code     string
+		lastStep int64
+		step     int64
+		ok       bool
+	}{
+		{"passo atual", code(current), 0, current, true},
+		{"relógio atrasado", code(current - 1), 0, current - 1, true},
+		{"relógio adiantado", code(current + 1), 0, current + 1, true},
+		{"fora da janela (antes)", code(current - 2), 0, 0, false},
+		{"fora da janela (depois)", code(current + 2), 0, 0, false},
+		{"código repetido", code(current), current, 0, false},
+		{"passo anterior ao último usado", code(current - 1), current, 0, false},
+		{"passo seguinte ao último usado", code(current + 1), current, current + 1, true},
+		{"tamanho errado", code(current)[:5], 0, 0, false},
+	}
+	for _, tc := range cases {
+		step, ok := verifyTOTP(rfcSecret, tc.code, now, tc.lastStep)
+		if ok != tc.ok || step != tc.step {
+			t.Errorf("%s: got (%d, %v), want (%d, %v)", tc.name, step, ok, tc.step, tc.ok)
+		}
+	}
+
+	if _, ok := verifyTOTP("não é base32!", code(current), now, 0); ok {
+		t.Error("invalid secret accepted")
+	}
+}
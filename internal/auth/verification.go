@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"sonnda-api/internal/mail"
+	"sonnda-api/internal/user"
+)
+
+const purposeEmailVerification = "email-verification"
+
+// emailVerifier emite e valida links de verificação de e-mail assinados com
+// HMAC. O token carrega o id do usuário, o e-mail e a validade; trocar o e-mail
+// invalida links antigos sem precisar de estado no banco. A segurança depende
+// só da chave do signer, por isso Config.Validate exige TOKEN_SIGNING_SECRET
+// forte antes da subida.
+type emailVerifier struct {
+	signer hmacSigner
+	ttl    time.Duration
+}
+
+func newEmailVerifier(signer hmacSigner, ttl time.Duration) *emailVerifier {
+	return &emailVerifier{signer: signer, ttl: ttl}
+}
+
+func (v *emailVerifier) Generate(userID uint, email string) string {
+	uid := strconv.FormatUint(uint64(userID), 10)
+	return v.signer.Sign(purposeEmailVerification, v.ttl, uid, email)
+}
+
+// Parse valida assinatura e validade e retorna o usuário e o e-mail do token.
+func (v *emailVerifier) Parse(token string) (uint, string, error) {
+	fields, err := v.signer.Verify(purposeEmailVerification, token)
+	if err != nil || len(fields) != 2 {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	uid, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidVerificationToken
+	}
+	return uint(uid), fields[1], nil
+}
+
+// VerificationSender envia o link de verificação de e-mail e registra o
+// envio. É o mesmo fluxo do /auth/register, exposto para outros cadastros
+// que criam contas (pacientes).
+type VerificationSender struct {
+	repo     Repository
+	mailer   mail.Mailer
+	verifier *emailVerifier
+	appURL   string
+}
+
+// NewVerificationSender falha (panic) com chave fraca, como NewService.
+func NewVerificationSender(repo Repository, mailer mail.Mailer, cfg Config) *VerificationSender {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
+	signer := hmacSigner{secret: []byte(cfg.SigningSecret)}
+	return &VerificationSender{
+		repo:     repo,
+		mailer:   mailer,
+		verifier: newEmailVerifier(signer, cfg.VerificationTTL),
+		appURL:   cfg.AppURL,
+	}
+}
+
+func (v *VerificationSender) Send(ctx context.Context, u *user.User) error {
+	token := v.verifier.Generate(u.ID, u.Email)
+	link := fmt.Sprintf("%s/verify-email?token=%s", v.appURL, url.QueryEscape(token))
+	msg := mail.Message{
+		To:      u.Email,
+		Subject: "Sonnda - Confirme seu e-mail",
+		Body: fmt.Sprintf("Bem-vindo(a) à Sonnda!\n\n"+
+			"Confirme seu e-mail acessando o link abaixo:\n%s\n\n"+
+			"Se você não criou esta conta, ignore este e-mail.", link),
+	}
+	if err := v.mailer.Send(ctx, msg); err != nil {
+		return err
+	}
+	return v.repo.MarkVerificationSent(ctx, u.ID, time.Now().UTC())
+}
@@ -0,0 +1,73 @@
+package brdocs
+
+// CNS (Cartão Nacional de Saúde): 15 dígitos. Os definitivos começam com 1 ou
+// 2 e derivam do PIS; os provisórios começam com 7, 8 ou 9. Nos dois casos a
+// soma ponderada (pesos 15..1) é múltipla de 11.
+
+// ValidCNS verifica um CNS definitivo ou provisório, com ou sem espaços.
+func ValidCNS(s string) bool {
+	if !hasOnlyDocumentChars(s) {
+		return false
+	}
+	d := OnlyDigits(s)
+	if len(d) != 15 {
+		return false
+	}
+	switch d[0] {
+	case '1', '2':
+		return validDefinitiveCNS(d)
+	case '7', '8', '9':
+		return cnsWeightedSum(d)%11 == 0
+	}
+	return false
+}
+
+// IsProvisionalCNS informa se o CNS (válido) é provisório.
+func IsProvisionalCNS(s string) bool {
+	d := OnlyDigits(s)
+	return len(d) == 15 && (d[0] == '7' || d[0] == '8' || d[0] == '9')
+}
+
+// validDefinitiveCNS refaz o cálculo do DATASUS: os 11 primeiros dígitos
+// (PIS) geram o sufixo "000"+dv, ou "001"+dv quando o dv daria 10.
+func validDefinitiveCNS(d string) bool {
+	pis := d[:11]
+	sum := 0
+	for i := 0; i < 11; i++ {
+		sum += int(pis[i]-'0') * (15 - i)
+	}
+	dv := 11 - sum%11
+	if dv == 11 {
+		dv = 0
+	}
+	suffix := "000"
+	if dv == 10 {
+		sum += 2
+		dv = 11 - sum%11
+		suffix = "001"
+	}
+	return d == pis+suffix+string(byte('0'+dv))
+}
+
+func cnsWeightedSum(d string) int {
+	sum := 0
+	for i := 0; i < len(d); i++ {
+		sum += int(d[i]-'0') * (15 - i)
+	}
+	return sum
+}
+
+// NormalizeCNS devolve só os dígitos do CNS.
+func NormalizeCNS(s string) string {
+	return OnlyDigits(s)
+}
+
+// FormatCNS formata como "123 4567 8901 2345". Valores que não tenham 15
+// dígitos voltam como vieram.
+func FormatCNS(s string) string {
+	d := OnlyDigits(s)
+	if len(d) != 15 {
+		return s
+	}
+	return d[:3] + " " + d[3:7] + " " + d[7:11] + " " + d[11:]
+}
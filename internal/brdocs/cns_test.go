@@ -0,0 +1,38 @@
+package brdocs
+
+import "testing"
+
+func TestValidCNS(t *testing.T) {
+	cases := []struct {
+		cns         string
+		want        bool
+		provisional bool
+	}{
+		// definitivos: PIS + "000" + dv
+		{"123456789010000", true, false},
+		{"200000000010009", true, false},
+		{"170 1234 5678 0008", true, false},
+		// dv daria 10: o DATASUS usa o sufixo "001" e recalcula
+		{"100000000060018", true, false},
+		{"100000000060008", false, false},
+		{"100000000060019", false, false},
+		// provisórios: soma ponderada múltipla de 11
+		{"700000000000110", true, true},
+		{"700000000000111", false, true},
+		{"898001160645317", false, true},
+		{"123456789010001", false, false},
+		{"300000000000000", false, false},
+		{"12345678901000", false, false},
+		{"", false, false},
+	}
+	for _, tc := range cases {
+		if got := ValidCNS(tc.cns); got != tc.want {
+			t.Errorf("ValidCNS(%q) = %v, want %v", tc.cns, got, tc.want)
+		}
+		if tc.want {
+			if got := IsProvisionalCNS(tc.cns); got != tc.provisional {
+				t.Errorf("IsProvisionalCNS(%q) = %v, want %v", tc.cns, got, tc.provisional)
+			}
+		}
+	}
+}
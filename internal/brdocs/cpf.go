@@ -0,0 +1,45 @@
+package brdocs
+
+// ValidCPF verifica tamanho e dígitos verificadores. Aceita o CPF com ou sem
+// pontuação; sequências repetidas ("111.111.111-11") são inválidas.
+func ValidCPF(s string) bool {
+	if !hasOnlyDocumentChars(s) {
+		return false
+	}
+	d := OnlyDigits(s)
+	if len(d) != 11 || allSame(d) {
+		return false
+	}
+	return cpfCheckDigit(d[:9]) == d[9] && cpfCheckDigit(d[:10]) == d[10]
+}
+
+// cpfCheckDigit calcula o próximo dígito verificador (módulo 11, pesos
+// decrescentes a partir de len+1).
+func cpfCheckDigit(digits string) byte {
+	sum := 0
+	weight := len(digits) + 1
+	for i := 0; i < len(digits); i++ {
+		sum += int(digits[i]-'0') * weight
+		weight--
+	}
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
+
+// NormalizeCPF devolve só os dígitos do CPF.
+func NormalizeCPF(s string) string {
+	return OnlyDigits(s)
+}
+
+// FormatCPF formata como "123.456.789-09". Valores que não tenham 11 dígitos
+// voltam como vieram.
+func FormatCPF(s string) string {
+	d := OnlyDigits(s)
+	if len(d) != 11 {
+		return s
+	}
+	return d[:3] + "." + d[3:6] + "." + d[6:9] + "-" + d[9:]
+}
@@ -0,0 +1,28 @@
+package brdocs
+
+import "testing"
+
+func TestValidCPF(t *testing.T) {
+	cases := []struct {
+		cpf  string
+		want bool
+	}{
+		{"529.982.247-25", true},
+		{"52998224725", true},
+		{"123.456.789-09", true},
+		{"000.000.001-91", true}, // resto < 2 no primeiro dígito
+		{"529.982.247-24", false},
+		{"529.982.247-15", false},
+		{"111.111.111-11", false}, // sequência repetida passa no cálculo
+		{"00000000000", false},
+		{"5299822472", false},
+		{"529982247250", false},
+		{"529.98a.247-25", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		if got := ValidCPF(tc.cpf); got != tc.want {
+			t.Errorf("ValidCPF(%q) = %v, want %v", tc.cpf, got, tc.want)
+		}
+	}
+}
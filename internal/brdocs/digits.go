@@ -0,0 +1,39 @@
+// Package brdocs valida, normaliza e formata documentos brasileiros (CPF e
+// CNS). Os valores normalizados têm apenas dígitos e são o que vai para o banco.
+package brdocs
+
+import "strings"
+
+// OnlyDigits remove tudo que não for dígito ("123.456.789-09" -> "12345678909").
+func OnlyDigits(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// hasOnlyDocumentChars aceita dígitos e a pontuação usual de documentos, para
+// que "123.456.789-09" valide mas "12a.456.789-09" não.
+func hasOnlyDocumentChars(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == '-', r == ' ', r == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func allSame(digits string) bool {
+	for i := 1; i < len(digits); i++ {
+		if digits[i] != digits[0] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package brdocs
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterValidators registra as tags "cpf" e "cns" no validator informado.
+func RegisterValidators(v *validator.Validate) error {
+	if err := v.RegisterValidation("cpf", func(fl validator.FieldLevel) bool {
+		return ValidCPF(fl.Field().String())
+	}); err != nil {
+		return err
+	}
+	return v.RegisterValidation("cns", func(fl validator.FieldLevel) bool {
+		return ValidCNS(fl.Field().String())
+	})
+}
+
+// RegisterGinValidators registra as tags no validator usado pelo binding do gin.
+func RegisterGinValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("gin binding validator is not go-playground/validator")
+	}
+	return RegisterValidators(v)
+}
@@ -0,0 +1,147 @@
+// Package cid10 carrega a tabela de códigos da CID-10 usada para codificar
+// problemas e diagnósticos.
+package cid10
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"sonnda-api/internal/textfold"
+)
+
+//go:embed cid10.csv
+var bundled string
+
+var ErrInvalidCode = errors.New("invalid CID-10 code")
+
+// Code é uma entrada da tabela (categoria "I10" ou subcategoria "E11.9").
+type Code struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// Catalog é a tabela carregada em memória; somente leitura após Load.
+type Catalog struct {
+	codes  map[string]Code
+	sorted []Code
+}
+
+// Load lê linhas "codigo;descricao". Linhas vazias e iniciadas por "#" são
+// ignoradas.
+func Load(r io.Reader) (*Catalog, error) {
+	c := &Catalog{codes: make(map[string]Code)}
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		raw, desc, ok := strings.Cut(text, ";")
+		if !ok {
+			return nil, fmt.Errorf("cid10: line %d: missing ';'", line)
+		}
+		code, err := Normalize(raw)
+		if err != nil {
+			return nil, fmt.Errorf("cid10: line %d: %w", line, err)
+		}
+		c.codes[code] = Code{Code: code, Description: strings.TrimSpace(desc)}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	c.sorted = make([]Code, 0, len(c.codes))
+	for _, code := range c.codes {
+		c.sorted = append(c.sorted, code)
+	}
+	sort.Slice(c.sorted, func(i, j int) bool { return c.sorted[i].Code < c.sorted[j].Code })
+	return c, nil
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultCatalog *Catalog
+	defaultErr     error
+)
+
+// Default retorna a tabela de CID10_TABLE, se definida, ou a embutida no binário.
+func Default() (*Catalog, error) {
+	defaultOnce.Do(func() {
+		if path := os.Getenv("CID10_TABLE"); path != "" {
+			f, err := os.Open(path)
+			if err != nil {
+				defaultErr = err
+				return
+			}
+			defer f.Close()
+			defaultCatalog, defaultErr = Load(f)
+			return
+		}
+		defaultCatalog, defaultErr = Load(strings.NewReader(bundled))
+	})
+	return defaultCatalog, defaultErr
+}
+
+// Normalize padroniza o código: maiúsculas e ponto antes do 4º caractere
+// ("e119" -> "E11.9"). Não consulta a tabela.
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, ".", "")
+	if len(code) < 3 || len(code) > 4 {
+		return "", ErrInvalidCode
+	}
+	if code[0] < 'A' || code[0] > 'Z' || !isDigit(code[1]) || !isDigit(code[2]) {
+		return "", ErrInvalidCode
+	}
+	if len(code) == 4 {
+		if !isDigit(code[3]) {
+			return "", ErrInvalidCode
+		}
+		return code[:3] + "." + code[3:], nil
+	}
+	return code, nil
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
+
+// Lookup busca o código (em qualquer grafia aceita por Normalize).
+func (c *Catalog) Lookup(code string) (Code, bool) {
+	normalized, err := Normalize(code)
+	if err != nil {
+		return Code{}, false
+	}
+	entry, ok := c.codes[normalized]
+	return entry, ok
+}
+
+// Search procura por prefixo de código ou por termo na descrição, sem
+// diferenciar acentos e maiúsculas. Sem resultados, retorna lista vazia (a
+// API responde [] e não null).
+func (c *Catalog) Search(query string, limit int) []Code {
+	out := []Code{}
+	q := textfold.Fold(strings.TrimSpace(query))
+	if q == "" {
+		return out
+	}
+	codeQuery := strings.ReplaceAll(strings.ToUpper(q), ".", "")
+
+	for _, entry := range c.sorted {
+		if strings.HasPrefix(strings.ReplaceAll(entry.Code, ".", ""), codeQuery) ||
+			strings.Contains(textfold.Fold(entry.Description), q) {
+			out = append(out, entry)
+			if len(out) == limit {
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package cid10
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testTable = `# comentário
+A09;Diarréia e gastroenterite de origem infecciosa presumível
+
+e119;Diabetes mellitus não-insulino-dependente - sem complicações
+E11.2;Diabetes mellitus não-insulino-dependente - com complicações renais
+I10;Hipertensão essencial (primária)
+K58.9;Síndrome do cólon irritável sem diarréia
+`
+
+func mustLoad(t *testing.T, table string) *Catalog {
+	t.Helper()
+	c, err := Load(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestLoadBundled(t *testing.T) {
+	c := mustLoad(t, bundled)
+	if _, ok := c.Lookup("E11.9"); !ok {
+		t.Error("bundled table without E11.9")
+	}
+}
+
+func TestLoadRejectsInvalid(t *testing.T) {
+	for _, table := range []string{"I10 Hipertensão", "X;Código curto", "I1A;Código inválido"} {
+		if _, err := Load(strings.NewReader(table)); err == nil {
+			t.Errorf("Load(%q): expected error", table)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"E11.9", "E11.9", false},
+		{"e119", "E11.9", false},
+		{" i10 ", "I10", false},
+		{"I10.", "I10", false},
+		{"E1", "", true},
+		{"E1190", "", true},
+		{"1E1", "", true},
+		{"E1A", "", true},
+		{"E11.A", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	c := mustLoad(t, testTable)
+	if entry, ok := c.Lookup("e11.9"); !ok || entry.Code != "E11.9" {
+		t.Errorf("Lookup(e11.9) = %+v, %v", entry, ok)
+	}
+	for _, code := range []string{"E11.0", "Z99", "xx"} {
+		if _, ok := c.Lookup(code); ok {
+			t.Errorf("Lookup(%q): found", code)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	c := mustLoad(t, testTable)
+	codes := func(list []Code) []string {
+		out := []string{}
+		for _, e := range list {
+			out = append(out, e.Code)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{"prefixo do código", "e11", 10, []string{"E11.2", "E11.9"}},
+		{"código com ponto", "E11.9", 10, []string{"E11.9"}},
+		{"sem acento", "diarreia", 10, []string{"A09", "K58.9"}},
+		{"com acento e caixa", "HIPERTENSÃO", 10, []string{"I10"}},
+		{"limite", "diabetes", 1, []string{"E11.2"}},
+		{"sem resultado", "fratura", 10, []string{}},
+		{"consulta vazia", "  ", 10, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.Search(tt.query, tt.limit)
+			if got == nil {
+				t.Fatal("got nil, want an empty slice")
+			}
+			if !reflect.DeepEqual(codes(got), tt.want) {
+				t.Errorf("got %v, want %v", codes(got), tt.want)
+			}
+		})
+	}
+}
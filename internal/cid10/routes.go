@@ -0,0 +1,33 @@
+package cid10
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes expõe a busca na tabela para quem codifica problemas.
+func Routes(rg *gin.RouterGroup, authenticate gin.HandlerFunc, catalog *Catalog) {
+	group := rg.Group("/cid10")
+	group.Use(authenticate)
+
+	// GET /api/v1/cid10?q=diabetes&limit=20
+	group.GET("", func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 100 {
+			limit = 20
+		}
+		c.JSON(http.StatusOK, catalog.Search(c.Query("q"), limit))
+	})
+
+	// GET /api/v1/cid10/:code
+	group.GET("/:code", func(c *gin.Context) {
+		entry, ok := catalog.Lookup(c.Param("code"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "cid_code_not_found"})
+			return
+		}
+		c.JSON(http.StatusOK, entry)
+	})
+}
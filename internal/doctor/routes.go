@@ -1,13 +1,14 @@
 package doctor
 
 import (
-	"sonnda-api/internal/middleware"
-	"sonnda-api/internal/user"
+	"sonnda-api/internal/rbac"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Routes(rg *gin.RouterGroup) {
+// Routes registra as rotas do módulo. authenticate é o middleware do
+// auth.Authenticator, compartilhado por todos os grupos protegidos.
+func Routes(rg *gin.RouterGroup, authenticate gin.HandlerFunc, policy *rbac.Policy) {
 	//repo := NewRepository(database.DB)
 	//svc := NewService(repo)
 	//handler := NewHandler(svc)
@@ -21,10 +22,10 @@ func Routes(rg *gin.RouterGroup) {
 		// GET /api/v1/doctors/:id
 		//doctors.GET("/:id", handler.GetByID)
 
-		// Rotas protegidas - apenas médicos
+		// Rotas protegidas - profissionais com acesso a pacientes
 		protected := doctors.Group("")
-		protected.Use(middleware.JWTAuthMiddleware())
-		protected.Use(middleware.RequireRole(user.RoleDoctor))
+		protected.Use(authenticate)
+		protected.Use(policy.Require(rbac.PermPatientRead))
 		{
 			// GET /api/v1/doctors/me
 			//protected.GET("/me", handler.Me)
@@ -44,8 +45,8 @@ func Routes(rg *gin.RouterGroup) {
 
 		// Rotas apenas para admins
 		adminOnly := doctors.Group("")
-		adminOnly.Use(middleware.JWTAuthMiddleware())
-		adminOnly.Use(middleware.RequireAdmin())
+		adminOnly.Use(authenticate)
+		adminOnly.Use(policy.Require(rbac.PermUserAdmin))
 		{
 			// POST /api/v1/doctors
 			//adminOnly.POST("", handler.Create)
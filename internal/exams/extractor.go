@@ -0,0 +1,153 @@
+package exam
+
+import (
+	"context"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"sonnda-api/internal/textfold"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// TextExtractor transforma o arquivo do laudo em texto. O resultado tem uma
+// linha por linha visual do documento, com colunas separadas por tab.
+type TextExtractor interface {
+	Extract(ctx context.Context, r io.ReaderAt, size int64) (string, error)
+}
+
+// PDFExtractor extrai o texto de PDFs com camada de texto, em Go puro. PDFs
+// escaneados (só imagem) saem vazios e precisam de um extrator com OCR.
+type PDFExtractor struct{}
+
+func NewPDFExtractor() *PDFExtractor {
+	return &PDFExtractor{}
+}
+
+// run é um trecho de texto contínuo, como o PDF o posiciona.
+type run struct {
+	x, y, size float64
+	text       string
+}
+
+func (e *PDFExtractor) Extract(ctx context.Context, r io.ReaderAt, size int64) (string, error) {
+	doc, err := pdf.NewReader(r, size)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	for i := 1; i <= doc.NumPage(); i++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		page := doc.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, line := range pageLines(page.Content().Text) {
+			out.WriteString(line)
+			out.WriteByte('\n')
+		}
+	}
+	return out.String(), nil
+}
+
+// pageLines junta os glifos em trechos (mesma posição de início), os trechos
+// em linhas (mesma altura) e devolve as linhas de cima para baixo.
+func pageLines(glyphs []pdf.Text) []string {
+	var runs []run
+	for _, g := range glyphs {
+		if n := len(runs); n > 0 && runs[n-1].x == g.X && runs[n-1].y == g.Y {
+			runs[n-1].text += g.S
+			continue
+		}
+		runs = append(runs, run{x: g.X, y: g.Y, size: g.FontSize, text: g.S})
+	}
+
+	// agrupa por linha com tolerância: sobrescritos e colunas desalinhadas
+	// por menos de um terço da fonte ficam na mesma linha
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].y > runs[j].y })
+	var lines [][]run
+	for _, r := range runs {
+		if n := len(lines); n > 0 && math.Abs(lines[n-1][0].y-r.y) <= r.size/3 {
+			lines[n-1] = append(lines[n-1], r)
+			continue
+		}
+		lines = append(lines, []run{r})
+	}
+
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		sort.SliceStable(line, func(i, j int) bool { return line[i].x < line[j].x })
+		if text := joinRuns(line); text != "" {
+			out = append(out, text)
+		}
+	}
+	return out
+}
+
+// joinRuns monta a linha a partir da largura estimada de cada trecho. Alguns
+// geradores quebram palavras em sílabas ("He " "mo " "glo") terminadas em
+// espaço de largura zero: se o próximo trecho começa onde o texto visível
+// acabou, é a mesma palavra. Distâncias grandes separam colunas (tab).
+func joinRuns(line []run) string {
+	var cols []string
+	var col strings.Builder
+	var end float64
+	for i, r := range line {
+		if i > 0 {
+			space := textWidth(" ", r.size)
+			gap := r.x - end
+			switch {
+			case gap > 3*space:
+				cols = append(cols, col.String())
+				col.Reset()
+			case gap < space/2:
+				prev := strings.TrimRight(col.String(), " ")
+				col.Reset()
+				col.WriteString(prev)
+			default:
+				col.WriteByte(' ')
+			}
+		}
+		col.WriteString(r.text)
+		end = r.x + textWidth(strings.TrimRight(r.text, " "), r.size)
+	}
+	cols = append(cols, col.String())
+
+	out := cols[:0]
+	for _, c := range cols {
+		if c = strings.Join(strings.Fields(c), " "); c != "" {
+			out = append(out, c)
+		}
+	}
+	return strings.Join(out, "\t")
+}
+
+// textWidth estima a largura do texto com as métricas da Helvetica/Arial,
+// a fonte da maioria dos laudos. Letras acentuadas usam a largura da base.
+func textWidth(s string, size float64) float64 {
+	units := 0
+	for _, r := range textfold.RemoveAccents(s) {
+		if r >= ' ' && r <= '~' {
+			units += helveticaWidths[r-' ']
+		} else {
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// helveticaWidths são as larguras (em milésimos de em) dos caracteres ASCII
+// 32..126 na Helvetica, conforme o AFM padrão do PDF.
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // ' '..'/'
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // '0'..'?'
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // '@'..'O'
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // 'P'..'_'
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // '`'..'o'
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // 'p'..'~'
+}
@@ -0,0 +1,72 @@
+package exam
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ledongthuc/pdf"
+)
+
+func TestJoinRuns(t *testing.T) {
+	// Helvetica 10pt: espaço = 2,78pt; "He" = 12,78pt; "Valor" = 23,34pt
+	cases := []struct {
+		name string
+		line []run
+		want string
+	}{
+		{"um trecho", []run{{x: 0, size: 10, text: "Glicose"}}, "Glicose"},
+		{"sílabas com espaço de largura zero", []run{
+			{x: 0, size: 10, text: "He "},
+			{x: 12.78, size: 10, text: "mo "},
+			{x: 12.78 + 13.89, size: 10, text: "glo"},
+		}, "Hemoglo"},
+		{"palavras", []run{
+			{x: 0, size: 10, text: "Valor"},
+			{x: 23.34 + 2.78, size: 10, text: "de"},
+		}, "Valor de"},
+		{"colunas", []run{
+			{x: 0, size: 10, text: "Glicose"},
+			{x: 150, size: 10, text: "95"},
+			{x: 200, size: 10, text: "mg/dL"},
+		}, "Glicose\t95\tmg/dL"},
+		{"espaços sobrando", []run{{x: 0, size: 10, text: "  Urina   tipo I "}}, "Urina tipo I"},
+		{"só espaços", []run{{x: 0, size: 10, text: "   "}}, ""},
+	}
+	for _, tc := range cases {
+		if got := joinRuns(tc.line); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPageLines(t *testing.T) {
+	cases := []struct {
+		name   string
+		glyphs []pdf.Text
+		want   []string
+	}{
+		{"de cima para baixo", []pdf.Text{
+			{X: 0, Y: 680, FontSize: 10, S: "Hemoglobina"},
+			{X: 0, Y: 700, FontSize: 10, S: "HEMOGRAMA"},
+		}, []string{"HEMOGRAMA", "Hemoglobina"}},
+		{"glifos do mesmo trecho", []pdf.Text{
+			{X: 0, Y: 700, FontSize: 10, S: "G"},
+			{X: 0, Y: 700, FontSize: 10, S: "li"},
+			{X: 0, Y: 700, FontSize: 10, S: "cose"},
+		}, []string{"Glicose"}},
+		{"colunas fora de ordem e desalinhadas", []pdf.Text{
+			{X: 150, Y: 699, FontSize: 10, S: "15,0"},
+			{X: 0, Y: 700, FontSize: 10, S: "Hemoglobina"},
+			{X: 200, Y: 701, FontSize: 10, S: "g/dL"},
+		}, []string{"Hemoglobina\t15,0\tg/dL"}},
+		{"linha vazia descartada", []pdf.Text{
+			{X: 0, Y: 700, FontSize: 10, S: " "},
+			{X: 0, Y: 680, FontSize: 10, S: "Leucócitos"},
+		}, []string{"Leucócitos"}},
+	}
+	for _, tc := range cases {
+		if got := pageLines(tc.glyphs); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package exam
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"sonnda-api/internal/patient"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadSize limita o laudo enviado; PDFs de laboratório têm poucos MB.
+const maxUploadSize = 20 << 20
+
+// Handler expõe o envio e a consulta de exames de um paciente.
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Upload trata POST /patients/:id/exams/upload (multipart, campo "file")
+func (h *Handler) Upload(c *gin.Context) {
+	if !patient.ScopeCovers(c, patient.RecordTypeExam) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "out_of_authorization_scope"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file_too_large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+	if header.Size > maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file_too_large"})
+		return
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+
+	patientID, _ := patient.PatientIDFromContext(c)
+	exam, err := h.svc.Upload(c, patientID, data)
+	if err != nil {
+		writeExamError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, exam)
+}
+
+// List trata GET /patients/:id/exams?limit=50&offset=0
+func (h *Handler) List(c *gin.Context) {
+	if !patient.ScopeCovers(c, patient.RecordTypeExam) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "out_of_authorization_scope"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	patientID, _ := patient.PatientIDFromContext(c)
+	exams, total, err := h.svc.List(c, patientID, limit, offset)
+	if err != nil {
+		writeExamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items":  exams,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// Get trata GET /patients/:id/exams/:examId
+func (h *Handler) Get(c *gin.Context) {
+	examID, err := strconv.ParseUint(c.Param("examId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id"})
+		return
+	}
+	if !patient.ScopeCovers(c, patient.RecordTypeExam) {
+		// fora do escopo é tratado como inexistente
+		writeExamError(c, ErrExamNotFound)
+		return
+	}
+
+	patientID, _ := patient.PatientIDFromContext(c)
+	exam, err := h.svc.Get(c, patientID, uint(examID))
+	if err != nil {
+		writeExamError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, exam)
+}
+
+func writeExamError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrExamNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam_not_found"})
+	case errors.Is(err, ErrUnsupportedFile):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "unsupported_file_type"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+	}
+}
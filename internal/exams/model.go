@@ -23,6 +23,16 @@ const (
 
 type ExamStatus string
 
+// ResultFlag é a interpretação do resultado frente à faixa de referência
+type ResultFlag string
+
+const (
+	FlagLow      ResultFlag = "low"
+	FlagNormal   ResultFlag = "normal"
+	FlagHigh     ResultFlag = "high"
+	FlagCritical ResultFlag = "critical"
+)
+
 type ExamMeta struct {
 	RawText    *string     `gorm:"type:text"     json:"raw_text,omitempty"`
 	FileLink   *string     `gorm:"size:255"      json:"file_link,omitempty"`
@@ -61,7 +71,7 @@ type Exam struct {
 	RawText      *string     `gorm:"type:text" json:"raw_text,omitempty"` // Texto bruto extraído (OCR)
 	Status       ExamStatus  `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
 	Observations *string     `gorm:"type:text" json:"observations,omitempty"`
-	FileLink     *string     `gorm:"size:255" json:"file_link,omitempty"`           // URL/Path do arquivo de imagem ou PDF
+	FileLink     *string     `gorm:"size:255" json:"file_link,omitempty"`           // chave do arquivo no Storage, nunca o caminho
 	Code         *string     `gorm:"size:50" json:"code,omitempty"`                 // Código do exame
 	CodeSystem   *CodeSystem `gorm:"type:varchar(20)" json:"code_system,omitempty"` // Sistema de codificação
 
@@ -70,7 +80,13 @@ type Exam struct {
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // Soft delete
-	Metadata  ExamMeta       `gorm:"constraint:OnDelete:CASCADE" json:"metadata"`
+	Metadata  ExamMeta       `gorm:"embedded;embeddedPrefix:meta_" json:"metadata"`
+}
+
+// TableName separa os laudos da tabela exams, que guarda os registros EXAM do
+// prontuário (patient.Exam).
+func (Exam) TableName() string {
+	return "lab_exams"
 }
 
 // AnalitoResult representa cada “linha” do exame, ou seja, um analito e seu valor
@@ -88,6 +104,11 @@ type AnalitoResult struct {
 	MaxValue *float64 `json:"max_value,omitempty"`
 	UnitRef  *string  `gorm:"size:20" json:"unit_ref,omitempty"`
 
+	Method         *string `gorm:"size:100" json:"method,omitempty"`
+	ReferenceRange *string `gorm:"type:text" json:"reference_range,omitempty"` // texto da referência no laudo
+	// Flag fica vazio quando não há faixa aplicável ou a unidade não confere
+	Flag *ResultFlag `gorm:"type:varchar(10);index" json:"flag,omitempty"`
+
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -107,7 +128,7 @@ type ValorReferencia struct {
 	IdadeMax  int     `gorm:"not null"` // Idade máxima
 	Sexo      string  `gorm:"size:1"`   // "F", "M", ou "" (ambos)
 	Parametro string  `gorm:"not null"` // Ex: "Hemoglobina", "LDL"
-	ValorMin  float64 // Valor mínimo
+	ValorMin  float64 // Valor mínimo, na unidade preferida do analito (labparser.Normalize)
 	ValorMax  float64 // Valor máximo
 }
 
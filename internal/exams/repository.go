@@ -0,0 +1,111 @@
+package exam
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(ctx context.Context, exam *Exam) error
+	// SaveResults grava o exame processado e substitui seus resultados.
+	SaveResults(ctx context.Context, exam *Exam) error
+	// UpdateStatus altera só o status e a observação do exame.
+	UpdateStatus(ctx context.Context, examID uint, status ExamStatus, observations string) error
+	FindByID(ctx context.Context, patientID, examID uint) (*Exam, error)
+	List(ctx context.Context, patientID uint, limit, offset int) ([]Exam, int64, error)
+	// FindReference busca a faixa cadastrada para o analito; idade negativa
+	// ou sexo vazio não filtram.
+	FindReference(ctx context.Context, parametro, sexo string, idade int) (*ValorReferencia, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, exam *Exam) error {
+	return r.db.WithContext(ctx).Create(exam).Error
+}
+
+func (r *repository) SaveResults(ctx context.Context, exam *Exam) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Results").Save(exam).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("exam_id = ?", exam.ID).Delete(&AnalitoResult{}).Error; err != nil {
+			return err
+		}
+		if len(exam.Results) == 0 {
+			return nil
+		}
+		for i := range exam.Results {
+			exam.Results[i].ID = 0
+			exam.Results[i].ExamID = exam.ID
+		}
+		return tx.Create(&exam.Results).Error
+	})
+}
+
+func (r *repository) UpdateStatus(ctx context.Context, examID uint, status ExamStatus, observations string) error {
+	return r.db.WithContext(ctx).
+		Model(&Exam{}).
+		Where("id = ?", examID).
+		Updates(map[string]any{"status": status, "observations": observations}).Error
+}
+
+func (r *repository) FindByID(ctx context.Context, patientID, examID uint) (*Exam, error) {
+	var exam Exam
+	err := r.db.WithContext(ctx).
+		Preload("Results").
+		Where("id = ? AND patient_id = ?", examID, patientID).
+		First(&exam).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &exam, nil
+}
+
+func (r *repository) List(ctx context.Context, patientID uint, limit, offset int) ([]Exam, int64, error) {
+	q := r.db.WithContext(ctx).Model(&Exam{}).Where("patient_id = ?", patientID)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var exams []Exam
+	err := q.Preload("Results").
+		Order("date DESC NULLS LAST, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&exams).Error
+	return exams, total, err
+}
+
+func (r *repository) FindReference(ctx context.Context, parametro, sexo string, idade int) (*ValorReferencia, error) {
+	q := r.db.WithContext(ctx).Where("LOWER(parametro) = LOWER(?)", parametro)
+	if sexo != "" {
+		q = q.Where("COALESCE(sexo, '') IN ('', ?)", sexo)
+	} else {
+		q = q.Where("COALESCE(sexo, '') = ''")
+	}
+	if idade >= 0 {
+		q = q.Where("idade_min <= ? AND idade_max >= ?", idade, idade)
+	}
+
+	var ref ValorReferencia
+	err := q.Order("sexo DESC NULLS LAST, id").First(&ref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
@@ -0,0 +1,27 @@
+package exam
+
+import (
+	"sonnda-api/internal/database"
+	"sonnda-api/internal/patient"
+	"sonnda-api/internal/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes registra os exames sob /patients/:id, com o mesmo consentimento do
+// prontuário (AccessGuard do módulo de pacientes).
+func Routes(rg *gin.RouterGroup, authenticate gin.HandlerFunc, guard *patient.AccessGuard, storage Storage, extractor TextExtractor) {
+	svc := NewService(NewRepository(database.DB), storage, extractor)
+	handler := NewHandler(svc)
+
+	exams := rg.Group("/patients/:id/exams")
+	exams.Use(authenticate)
+	{
+		readExams := guard.Require("id", rbac.PermPatientRead, rbac.PermExamRead)
+		writeExams := guard.Require("id", rbac.PermPatientRead, rbac.PermExamWrite)
+
+		exams.POST("/upload", writeExams, handler.Upload)
+		exams.GET("", readExams, handler.List)
+		exams.GET("/:examId", readExams, handler.Get)
+	}
+}
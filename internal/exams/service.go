@@ -0,0 +1,328 @@
+package exam
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"sonnda-api/internal/labparser"
+	"sonnda-api/internal/textfold"
+)
+
+const (
+	defaultExamLimit = 50
+	maxExamLimit     = 200
+)
+
+var (
+	ErrExamNotFound    = errors.New("exam not found")
+	ErrUnsupportedFile = errors.New("unsupported file type")
+)
+
+var pdfMagic = []byte("%PDF-")
+
+type Service interface {
+	Upload(ctx context.Context, patientID uint, data []byte) (*Exam, error)
+	Get(ctx context.Context, patientID, examID uint) (*Exam, error)
+	List(ctx context.Context, patientID uint, limit, offset int) ([]Exam, int64, error)
+}
+
+type service struct {
+	repo      Repository
+	storage   Storage
+	extractor TextExtractor
+}
+
+func NewService(repo Repository, storage Storage, extractor TextExtractor) Service {
+	return &service{repo: repo, storage: storage, extractor: extractor}
+}
+
+// Upload guarda o laudo, cria o exame como pendente e o processa na hora:
+// extração do texto, parser e gravação dos resultados. Laudos que o parser
+// não entende ficam como needs_review, com o texto bruto para revisão manual.
+func (s *service) Upload(ctx context.Context, patientID uint, data []byte) (*Exam, error) {
+	if !bytes.HasPrefix(data, pdfMagic) {
+		return nil, ErrUnsupportedFile
+	}
+
+	key, err := s.storage.Save(ctx, patientID, ".pdf", data)
+	if err != nil {
+		return nil, err
+	}
+	exam := &Exam{
+		PatientID: patientID,
+		Name:      "Laudo",
+		Key:       "laudo",
+		Status:    StatusPending,
+		FileLink:  &key,
+	}
+	if err := s.repo.Create(ctx, exam); err != nil {
+		// sem o exame, ninguém mais chega ao arquivo
+		if derr := s.storage.Delete(ctx, key); derr != nil {
+			log.Printf("⚠️  Falha ao remover o arquivo %s: %v", key, derr)
+		}
+		return nil, err
+	}
+
+	s.process(ctx, exam, data)
+	if err := s.repo.SaveResults(ctx, exam); err != nil {
+		// não deixa o exame pendente para sempre: vai para revisão manual
+		if serr := s.repo.UpdateStatus(ctx, exam.ID, StatusNeedsReview, "falha ao gravar os resultados"); serr != nil {
+			log.Printf("⚠️  Falha ao marcar o exame %d para revisão: %v", exam.ID, serr)
+		}
+		return nil, err
+	}
+	return exam, nil
+}
+
+func (s *service) process(ctx context.Context, exam *Exam, data []byte) {
+	exam.Status = StatusNeedsReview
+
+	text, err := s.extractor.Extract(ctx, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		log.Printf("⚠️  Falha ao extrair texto do exame %d: %v", exam.ID, err)
+		note := "falha na extração do texto"
+		exam.Observations = &note
+		return
+	}
+	if strings.TrimSpace(text) == "" {
+		// PDF só com imagem: precisa de OCR ou digitação
+		note := "laudo sem camada de texto"
+		exam.Observations = &note
+		return
+	}
+	exam.RawText = &text
+
+	report, err := labparser.Parse(text)
+	if err != nil {
+		log.Printf("⚠️  Falha ao interpretar o exame %d: %v", exam.ID, err)
+		return
+	}
+	applyReport(exam, report)
+	s.interpret(ctx, exam, report)
+	if report.Complete() {
+		exam.Status = StatusProcessed
+	}
+}
+
+// applyReport copia o laudo interpretado para o exame, respeitando o tamanho
+// das colunas.
+func applyReport(exam *Exam, r *labparser.Report) {
+	exam.LabName = clip(r.LabName, 30)
+	exam.CNES = clip(r.CNES, 12)
+	exam.RegistroCRBM = clip(r.CRBM, 12)
+	exam.Paciente = clip(r.PatientName, 80)
+	exam.Solicitante = clip(r.Requester, 80)
+	exam.Codigo = clip(r.PatientCode, 10)
+	exam.DataDeNascimento = clip(r.BirthDate, 10)
+	if r.Age > 0 {
+		exam.Idade = clip(strconv.Itoa(r.Age), 3)
+	}
+	exam.Sexo = clip(r.Sex, 1)
+	exam.Convenio = clip(r.Convenio, 10)
+	if r.CollectedAt != nil {
+		exam.DataDeColeta = r.CollectedAt.Format("02/01/2006")
+		exam.Date = r.CollectedAt
+	}
+
+	sections := r.Sections()
+	if len(sections) == 0 {
+		return
+	}
+	exam.Name = clip(strings.Join(sections, ", "), 100)
+	exam.Key = clip(examKey(sections[0]), 100)
+
+	exam.Results = make([]AnalitoResult, 0, len(r.Analytes))
+	for _, a := range r.Analytes {
+		exam.Results = append(exam.Results, resultFromAnalyte(a))
+	}
+	if m := exam.Results[0].Method; m != nil && len(sections) == 1 {
+		exam.Method = m
+	}
+}
+
+// resultFromAnalyte converte uma linha do laudo em AnalitoResult. O valor
+// original fica sempre em ValueString; ValueNumeric só para números, já na
+// unidade preferida do analito, para que as séries sejam comparáveis. Na
+// conversão, ValueString leva também a unidade original ("7,0 mmol/L").
+func resultFromAnalyte(a labparser.Analyte) AnalitoResult {
+	result := AnalitoResult{
+		Name:        clip(a.Name, 100),
+		ValueString: optional(a.Value, 50),
+		Unit:        optional(labparser.CanonicalUnit(a.Unit), 20),
+		Method:      optional(a.Method, 100),
+	}
+	if a.Numeric != nil {
+		v, unit := labparser.Normalize(a.Name, *a.Numeric, a.Unit)
+		result.ValueNumeric = &v
+		result.Unit = optional(unit, 20)
+		if !labparser.SameUnit(unit, a.Unit) {
+			result.ValueString = optional(a.Value+" "+a.Unit, 50)
+		}
+	}
+	if a.ReferenceRange != "" {
+		ref := a.ReferenceRange
+		result.ReferenceRange = &ref
+	}
+	return result
+}
+
+// interpret preenche a faixa de cada resultado e o classifica. A faixa vem da
+// referência do laudo ou, na falta dela, de ValorReferencia. Os limites vão
+// para a mesma unidade do resultado gravado.
+func (s *service) interpret(ctx context.Context, exam *Exam, r *labparser.Report) {
+	critical, err := labparser.DefaultCriticalValues()
+	if err != nil {
+		log.Printf("⚠️  Falha ao carregar valores críticos: %v", err)
+	}
+	age := -1
+	if r.Age > 0 {
+		age = r.Age
+	}
+
+	// exam.Results segue a ordem de r.Analytes (applyReport)
+	for i, a := range r.Analytes {
+		res := &exam.Results[i]
+		ranges := labparser.ParseReference(a.ReferenceRange)
+		rng := labparser.SelectRange(ranges, r.Sex, float64(age))
+		if rng != nil {
+			rng = normalizeRange(a.Name, rng, a.Unit)
+		} else if res.ValueNumeric != nil {
+			ref, err := s.repo.FindReference(ctx, res.Name, r.Sex, age)
+			if err != nil {
+				log.Printf("⚠️  Falha ao buscar referência de %q: %v", res.Name, err)
+			} else if ref != nil {
+				rng = &labparser.Range{Min: &ref.ValorMin, Max: &ref.ValorMax, Unit: deref(res.Unit)}
+			}
+		}
+
+		if rng != nil {
+			res.MinValue, res.MaxValue = rng.Min, rng.Max
+			res.UnitRef = optional(rng.Unit, 20)
+		}
+		isCritical := a.Numeric != nil && labparser.InCriticalZone(ranges, *a.Numeric)
+		if !isCritical && critical != nil && res.ValueNumeric != nil {
+			isCritical = critical.IsCritical(a.Section, res.Name, deref(res.Unit), *res.ValueNumeric)
+		}
+		res.Flag = flagResult(res, rng, isCritical)
+	}
+}
+
+// normalizeRange leva os limites à unidade preferida do analito. Faixa sem
+// unidade está na unidade do resultado no laudo.
+func normalizeRange(name string, rng *labparser.Range, unit string) *labparser.Range {
+	out := *rng
+	if out.Unit != "" {
+		unit = out.Unit
+	}
+	out.Unit = labparser.CanonicalUnit(unit)
+	if out.Min != nil {
+		v, u := labparser.Normalize(name, *out.Min, unit)
+		out.Min, out.Unit = &v, u
+	}
+	if out.Max != nil {
+		v, u := labparser.Normalize(name, *out.Max, unit)
+		out.Max, out.Unit = &v, u
+	}
+	return &out
+}
+
+// flagResult classifica o resultado. Valor crítico (da referência do laudo
+// ou da tabela) vale mesmo sem faixa; unidade diferente da faixa não é
+// comparada.
+func flagResult(res *AnalitoResult, rng *labparser.Range, critical bool) *ResultFlag {
+	flag := FlagNormal
+	if res.ValueNumeric == nil {
+		if !normalText(res) {
+			return nil
+		}
+		return &flag
+	}
+
+	v, unit := *res.ValueNumeric, deref(res.Unit)
+	if critical {
+		flag = FlagCritical
+		return &flag
+	}
+	if rng == nil || (unit != "" && rng.Unit != "" && !labparser.SameUnit(unit, rng.Unit)) {
+		return nil
+	}
+	switch rng.Compare(v) {
+	case -1:
+		flag = FlagLow
+	case 1:
+		flag = FlagHigh
+	}
+	return &flag
+}
+
+// normalText reconhece resultados textuais iguais à referência ("Negativo").
+func normalText(res *AnalitoResult) bool {
+	if res.ValueString == nil || res.ValueNumeric != nil || res.ReferenceRange == nil {
+		return false
+	}
+	squash := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(textfold.RemoveAccents(s)), ""))
+	}
+	return squash(*res.ValueString) == squash(*res.ReferenceRange)
+}
+
+func (s *service) Get(ctx context.Context, patientID, examID uint) (*Exam, error) {
+	exam, err := s.repo.FindByID(ctx, patientID, examID)
+	if err != nil {
+		return nil, err
+	}
+	if exam == nil {
+		return nil, ErrExamNotFound
+	}
+	return exam, nil
+}
+
+func (s *service) List(ctx context.Context, patientID uint, limit, offset int) ([]Exam, int64, error) {
+	if limit <= 0 {
+		limit = defaultExamLimit
+	}
+	if limit > maxExamLimit {
+		limit = maxExamLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.List(ctx, patientID, limit, offset)
+}
+
+// examKey gera a chave estável do exame: "CREATININA" → "creatinina",
+// "PCR-PROTEINA C REATIVA" → "pcr_proteina_c_reativa".
+func examKey(name string) string {
+	fields := strings.FieldsFunc(textfold.Fold(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, "_")
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func optional(s string, n int) *string {
+	if s = clip(s, n); s == "" {
+		return nil
+	}
+	return &s
+}
+
+// clip corta s em n caracteres (não bytes).
+func clip(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if r := []rune(s); len(r) > n {
+		return strings.TrimSpace(string(r[:n]))
+	}
+	return s
+}
@@ -0,0 +1,190 @@
+package exam
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"sonnda-api/internal/labparser"
+)
+
+type fakeRepo struct {
+	createErr, saveErr error
+	created, saved     *Exam
+	status             ExamStatus
+}
+
+func (r *fakeRepo) Create(ctx context.Context, exam *Exam) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	exam.ID = 7
+	r.created = exam
+	return nil
+}
+
+func (r *fakeRepo) SaveResults(ctx context.Context, exam *Exam) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	r.saved = exam
+	return nil
+}
+
+func (r *fakeRepo) UpdateStatus(ctx context.Context, examID uint, status ExamStatus, observations string) error {
+	r.status = status
+	return nil
+}
+
+func (r *fakeRepo) FindByID(ctx context.Context, patientID, examID uint) (*Exam, error) {
+	return nil, nil
+}
+
+func (r *fakeRepo) List(ctx context.Context, patientID uint, limit, offset int) ([]Exam, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeRepo) FindReference(ctx context.Context, parametro, sexo string, idade int) (*ValorReferencia, error) {
+	return nil, nil
+}
+
+type fakeStorage struct {
+	saved, deleted []string
+}
+
+func (s *fakeStorage) Save(ctx context.Context, patientID uint, ext string, data []byte) (string, error) {
+	key := "1/abc" + ext
+	s.saved = append(s.saved, key)
+	return key, nil
+}
+
+func (s *fakeStorage) Delete(ctx context.Context, key string) error {
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+type fakeExtractor struct {
+	text string
+	err  error
+}
+
+func (e fakeExtractor) Extract(ctx context.Context, r io.ReaderAt, size int64) (string, error) {
+	return e.text, e.err
+}
+
+var pdfData = []byte("%PDF-1.4 laudo")
+
+func TestUploadRejectsNonPDF(t *testing.T) {
+	svc := NewService(&fakeRepo{}, &fakeStorage{}, fakeExtractor{})
+	if _, err := svc.Upload(context.Background(), 1, []byte("GIF89a")); !errors.Is(err, ErrUnsupportedFile) {
+		t.Fatalf("got %v, want ErrUnsupportedFile", err)
+	}
+}
+
+func TestUploadRemovesFileWhenCreateFails(t *testing.T) {
+	repo := &fakeRepo{createErr: errors.New("db down")}
+	storage := &fakeStorage{}
+	svc := NewService(repo, storage, fakeExtractor{})
+
+	if _, err := svc.Upload(context.Background(), 1, pdfData); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(storage.deleted) != 1 || storage.deleted[0] != storage.saved[0] {
+		t.Fatalf("stored file not removed: saved %v, deleted %v", storage.saved, storage.deleted)
+	}
+}
+
+func TestUploadMarksNeedsReviewWhenSaveFails(t *testing.T) {
+	repo := &fakeRepo{saveErr: errors.New("db down")}
+	svc := NewService(repo, &fakeStorage{}, fakeExtractor{text: "texto"})
+
+	if _, err := svc.Upload(context.Background(), 1, pdfData); err == nil {
+		t.Fatal("expected error")
+	}
+	if repo.status != StatusNeedsReview {
+		t.Fatalf("status %q, want %q", repo.status, StatusNeedsReview)
+	}
+}
+
+func TestUploadReturnsStorageKey(t *testing.T) {
+	repo := &fakeRepo{}
+	svc := NewService(repo, &fakeStorage{}, fakeExtractor{})
+
+	exam, err := svc.Upload(context.Background(), 1, pdfData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exam.FileLink == nil || *exam.FileLink != "1/abc.pdf" {
+		t.Fatalf("file link %v, want the storage key", exam.FileLink)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	report, err := os.ReadFile("../labparser/testdata/excelencia.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		extractor fakeExtractor
+		status    ExamStatus
+		results   int
+		note      string
+	}{
+		{"falha na extração", fakeExtractor{err: errors.New("corrupted")}, StatusNeedsReview, 0, "falha na extração do texto"},
+		{"sem texto", fakeExtractor{text: " \n "}, StatusNeedsReview, 0, "laudo sem camada de texto"},
+		{"laudo desconhecido", fakeExtractor{text: "Recibo de consulta\nValor: R$ 100,00"}, StatusNeedsReview, 0, ""},
+		{"laudo interpretado", fakeExtractor{text: string(report)}, StatusProcessed, 2, ""},
+	}
+	for _, tc := range cases {
+		s := &service{repo: &fakeRepo{}, storage: &fakeStorage{}, extractor: tc.extractor}
+		exam := &Exam{ID: 1, Status: StatusPending}
+		s.process(context.Background(), exam, pdfData)
+
+		if exam.Status != tc.status {
+			t.Errorf("%s: status %q, want %q", tc.name, exam.Status, tc.status)
+		}
+		if len(exam.Results) != tc.results {
+			t.Errorf("%s: %d results, want %d", tc.name, len(exam.Results), tc.results)
+		}
+		if got := deref(exam.Observations); got != tc.note {
+			t.Errorf("%s: observations %q, want %q", tc.name, got, tc.note)
+		}
+	}
+}
+
+func TestFlagResult(t *testing.T) {
+	num := func(v float64) *float64 { return &v }
+	str := func(s string) *string { return &s }
+	rng := &labparser.Range{Min: num(70), Max: num(99), Unit: "mg/dL"}
+
+	cases := []struct {
+		name     string
+		res      AnalitoResult
+		rng      *labparser.Range
+		critical bool
+		want     string
+	}{
+		{"dentro", AnalitoResult{ValueNumeric: num(85), Unit: str("mg/dL")}, rng, false, "normal"},
+		{"limite", AnalitoResult{ValueNumeric: num(99), Unit: str("mg/dL")}, rng, false, "normal"},
+		{"abaixo", AnalitoResult{ValueNumeric: num(60), Unit: str("mg/dL")}, rng, false, "low"},
+		{"acima", AnalitoResult{ValueNumeric: num(130), Unit: str("mg/dL")}, rng, false, "high"},
+		{"crítico sem faixa", AnalitoResult{ValueNumeric: num(30), Unit: str("mg/dL")}, nil, true, "critical"},
+		{"sem faixa", AnalitoResult{ValueNumeric: num(85), Unit: str("mg/dL")}, nil, false, ""},
+		{"outra unidade", AnalitoResult{ValueNumeric: num(5), Unit: str("mmol/L")}, rng, false, ""},
+		{"texto igual à referência", AnalitoResult{ValueString: str("Não reagente"), ReferenceRange: str("Não Reagente")}, nil, false, "normal"},
+		{"texto diferente", AnalitoResult{ValueString: str("Reagente"), ReferenceRange: str("Não Reagente")}, nil, false, ""},
+	}
+	for _, tc := range cases {
+		got := ""
+		if flag := flagResult(&tc.res, tc.rng, tc.critical); flag != nil {
+			got = string(*flag)
+		}
+		if got != tc.want {
+			t.Errorf("%s: flag %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package exam
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var ErrInvalidStorageKey = errors.New("invalid storage key")
+
+// Storage guarda os arquivos originais dos laudos. Save devolve uma chave
+// opaca ("12/3f9c...e1.pdf"), que vai para Exam.FileLink: o caminho real
+// fica só com a implementação.
+type Storage interface {
+	Save(ctx context.Context, patientID uint, ext string, data []byte) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStorageFromEnv grava em disco, no diretório EXAM_STORAGE_DIR
+// (padrão: storage/exams).
+func NewStorageFromEnv() Storage {
+	dir := os.Getenv("EXAM_STORAGE_DIR")
+	if dir == "" {
+		dir = filepath.Join("storage", "exams")
+	}
+	return NewLocalStorage(dir)
+}
+
+// LocalStorage grava os arquivos em dir/<paciente>/<nome aleatório>.
+type LocalStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+// reStorageKey aceita só chaves geradas por Save, sem "..".
+var reStorageKey = regexp.MustCompile(`^\d+/[0-9a-f]{32}(\.[a-z0-9]+)?$`)
+
+func (s *LocalStorage) Save(ctx context.Context, patientID uint, ext string, data []byte) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%d/%s%s", patientID, hex.EncodeToString(buf), ext)
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Delete remove o arquivo; chave inexistente não é erro.
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	if !reStorageKey.MatchString(key) {
+		return ErrInvalidStorageKey
+	}
+	err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package labparser
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"sonnda-api/internal/textfold"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed critical.yaml
+var bundledCritical []byte
+
+// CriticalValues é a tabela versionada de valores críticos por analito.
+// Os limites valem para sangue: seções de outros materiais (SkipSections,
+// como "Sumário de urina") nunca são comparadas.
+type CriticalValues struct {
+	Version      string          `yaml:"version"`
+	Source       string          `yaml:"source"`
+	SkipSections []string        `yaml:"skip_sections"`
+	Values       []CriticalValue `yaml:"values"`
+}
+
+type CriticalValue struct {
+	ID      string   `yaml:"id"`
+	Match   []string `yaml:"match"`
+	Exclude []string `yaml:"exclude"` // palavras que tiram o analito da regra (CHCM)
+	Unit    string   `yaml:"unit"`
+	Low     *float64 `yaml:"low"`
+	High    *float64 `yaml:"high"`
+}
+
+// ParseCriticalValues lê e valida a tabela em YAML.
+func ParseCriticalValues(data []byte) (*CriticalValues, error) {
+	var c CriticalValues
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if c.Version == "" {
+		return nil, errors.New("labparser: critical values without version")
+	}
+	seen := make(map[string]bool)
+	for i := range c.Values {
+		v := &c.Values[i]
+		switch {
+		case v.ID == "" || seen[v.ID]:
+			return nil, fmt.Errorf("labparser: critical value %d: missing or duplicated id", i)
+		case len(v.Match) == 0 || v.Unit == "":
+			return nil, fmt.Errorf("labparser: critical value %s: needs match and unit", v.ID)
+		case v.Low == nil && v.High == nil:
+			return nil, fmt.Errorf("labparser: critical value %s: needs low or high", v.ID)
+		}
+		seen[v.ID] = true
+		foldAll(v.Match)
+		foldAll(v.Exclude)
+	}
+	foldAll(c.SkipSections)
+	return &c, nil
+}
+
+var (
+	criticalOnce   sync.Once
+	criticalValues *CriticalValues
+	criticalErr    error
+)
+
+// DefaultCriticalValues retorna a tabela de LAB_CRITICAL_VALUES, se
+// definido, ou a embutida.
+func DefaultCriticalValues() (*CriticalValues, error) {
+	criticalOnce.Do(func() {
+		data := bundledCritical
+		if path := os.Getenv("LAB_CRITICAL_VALUES"); path != "" {
+			if data, criticalErr = os.ReadFile(path); criticalErr != nil {
+				return
+			}
+		}
+		criticalValues, criticalErr = ParseCriticalValues(data)
+	})
+	return criticalValues, criticalErr
+}
+
+func foldAll(terms []string) {
+	for i, t := range terms {
+		terms[i] = textfold.Fold(t)
+	}
+}
+
+// IsCritical informa se o resultado do analito passa de um limite crítico.
+// Só compara na mesma unidade e fora das seções de outros materiais.
+func (c *CriticalValues) IsCritical(section, name, unit string, value float64) bool {
+	if sec := wordsOf(section); containsAny(sec, c.SkipSections) {
+		return false
+	}
+	words := wordsOf(name)
+	for _, v := range c.Values {
+		if !SameUnit(v.Unit, unit) || !containsAny(words, v.Match) || containsAny(words, v.Exclude) {
+			continue
+		}
+		return (v.Low != nil && value < *v.Low) || (v.High != nil && value > *v.High)
+	}
+	return false
+}
+
+// wordsOf devolve as palavras de s, sem acentos, entre espaços
+// (" concentracao de hemoglobina ").
+func wordsOf(s string) string {
+	return " " + strings.Join(strings.FieldsFunc(textfold.Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ") + " "
+}
+
+// containsAny procura os termos como palavras inteiras.
+func containsAny(words string, terms []string) bool {
+	for _, t := range terms {
+		if strings.Contains(words, " "+t+" ") {
+			return true
+		}
+	}
+	return false
+}
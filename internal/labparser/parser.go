@@ -0,0 +1,445 @@
+// Package labparser interpreta o texto extraído de laudos laboratoriais.
+package labparser
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Report é o que conseguimos ler do laudo: cabeçalho e todos os resultados.
+type Report struct {
+	// Laboratório
+	Profile string `json:"profile"` // perfil de layout usado na leitura
+	LabName string `json:"lab_name,omitempty"`
+	CNES    string `json:"cnes,omitempty"`
+	CRBM    string `json:"crbm,omitempty"`
+
+	// Paciente
+	PatientName string     `json:"patient_name,omitempty"`
+	Requester   string     `json:"requester,omitempty"`
+	PatientCode string     `json:"patient_code,omitempty"`
+	BirthDate   string     `json:"birth_date,omitempty"` // dd/mm/aaaa, como no laudo
+	Age         int        `json:"age,omitempty"`
+	Sex         string     `json:"sex,omitempty"`
+	Convenio    string     `json:"convenio,omitempty"`
+	CollectedAt *time.Time `json:"collected_at,omitempty"`
+
+	Analytes []Analyte `json:"analytes"`
+}
+
+// Analyte é uma linha de resultado do laudo.
+type Analyte struct {
+	Section        string   `json:"section"` // exame ou painel (ex.: HEMOGRAMA)
+	Name           string   `json:"name"`
+	Value          string   `json:"value"`             // como no laudo: "4,75", "< 6", "Não reagente"
+	Numeric        *float64 `json:"numeric,omitempty"` // nil para valores textuais
+	Unit           string   `json:"unit,omitempty"`
+	Method         string   `json:"method,omitempty"`
+	ReferenceRange string   `json:"reference_range,omitempty"` // texto do laudo; uma faixa por linha
+}
+
+// Complete informa se o laudo rendeu algum resultado.
+func (r *Report) Complete() bool {
+	return len(r.Analytes) > 0
+}
+
+// Sections lista os exames do laudo, na ordem em que aparecem.
+func (r *Report) Sections() []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, a := range r.Analytes {
+		if !seen[a.Section] {
+			seen[a.Section] = true
+			out = append(out, a.Section)
+		}
+	}
+	return out
+}
+
+// Padrões estruturais, iguais em todos os layouts. Os rótulos de cada
+// laboratório ficam nos perfis (profiles/*.yaml).
+var (
+	reDate     = regexp.MustCompile(`\d{2}/\d{2}/\d{4}`)
+	reTitle    = regexp.MustCompile(`^\p{Lu}[\p{Lu}\d ()\-/.]{2,}:?$`)
+	reValue    = regexp.MustCompile(`^([<>≤≥]?\s*\d[\d.,]*)\s*(.*)$`)
+	reRangeish = regexp.MustCompile(`(?i)\d\s*(?:a|-|até)\s*\d|[<>≤≥]\s*\d|inferior|superior|até|acima|abaixo`)
+)
+
+// qualitative são os resultados textuais aceitos em tabelas ("Nitrito
+// Negativo"), comparados sem espaços e em minúsculas.
+var qualitative = map[string]bool{
+	"negativo": true, "negativa": true, "positivo": true, "positiva": true,
+	"ausente": true, "ausentes": true, "presente": true, "presentes": true,
+	"reagente": true, "nãoreagente": true, "naoreagente": true,
+	"normal": true, "escasso": true, "escassos": true, "raros": true, "raras": true, "numerosos": true,
+	"límpido": true, "limpido": true, "turvo": true, "ligeiramenteturvo": true,
+	"amarelo": true, "amareloclaro": true, "amarelocitrino": true, "amareloescuro": true,
+	"incolor": true, "âmbar": true, "ambar": true,
+	"indetectável": true, "indetectavel": true, "detectável": true, "detectavel": true,
+}
+
+// section acumula os resultados de um exame até o "Liberado em".
+type section struct {
+	title    string
+	method   string
+	analytes []Analyte
+	inline   map[int]bool // analitos de "Resultado:", que usam o bloco de referência
+	refLines []string
+	refOpen  bool // dentro do bloco "Valor de referência"
+	started  bool // já houve conteúdo além do título
+	lastRow  int  // última linha de tabela, para faixas que continuam abaixo (-1: nenhuma)
+}
+
+// Parse lê o cabeçalho e todos os resultados do laudo. Cada exame começa num
+// título em maiúsculas (ou "Exame: X") e termina no "Liberado em"; dentro
+// dele são reconhecidos "Resultado: valor" e linhas de tabela
+// "Analito  valor unidade  referência", com um ou dois valores.
+func (p *Profile) Parse(text string) (*Report, error) {
+	r := &Report{Profile: p.Name, LabName: p.LabName}
+	res := p.results
+	header := map[string]string{}
+	var cur *section
+	var wantValue bool // "Resultado:" sozinho: o valor vem numa das próximas linhas
+	var pending string // campo de cabeçalho à espera da próxima coluna
+
+	closeSection := func() {
+		if cur != nil {
+			r.Analytes = append(r.Analytes, cur.finish()...)
+		}
+		cur, wantValue = nil, false
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		cells := splitCells(scanner.Text())
+		if len(cells) == 0 {
+			continue
+		}
+		if p.parseHeader(cells, header, &pending) {
+			continue
+		}
+		line := strings.Join(cells, " ")
+
+		switch {
+		case res["exam"].MatchString(cells[0]):
+			closeSection()
+			cur = newSection(res["exam"].FindStringSubmatch(cells[0])[1])
+		case isTitle(cells):
+			title := strings.TrimSuffix(strings.Join(titleCells(cells), " "), ":")
+			if cur == nil || !cur.started {
+				// vale o último título antes do conteúdo (o logo do
+				// laboratório às vezes vem como texto)
+				cur = newSection(title)
+			} else {
+				// subtítulo (ERITROGRAMA, LEUCOGRAMA…)
+				cur.lastRow = -1
+			}
+			if isReferenceHeader(cells[len(cells)-1:]) {
+				cur.refOpen = true
+			}
+		case cur == nil:
+		case !markStarted(cur):
+		case res["released"].MatchString(line):
+			closeSection()
+		case res["result"].MatchString(cells[0]):
+			rest := append([]string{res["result"].FindStringSubmatch(cells[0])[1]}, cells[1:]...)
+			wantValue = !cur.addResult(rest)
+		case wantValue && reValue.MatchString(cells[0]):
+			wantValue = !cur.addResult(cells)
+		case res["stop"].MatchString(cells[0]) || res["method"].MatchString(line):
+			if m := res["method"].FindStringSubmatch(line); m != nil {
+				cur.setMethod(m[1])
+			}
+			cur.refOpen, cur.lastRow = false, -1
+		case isReferenceHeader(cells):
+			// referência abaixo de uma linha de tabela vale para o exame todo
+			cur.refOpen, cur.lastRow = true, -1
+		case p.isName(cells[0]) && cur.addRow(cells):
+		case cur.lastRow >= 0 && reRangeish.MatchString(line):
+			a := &cur.analytes[cur.lastRow]
+			a.ReferenceRange = joinLines(a.ReferenceRange, line)
+		case cur.refOpen:
+			cur.refLines = append(cur.refLines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	closeSection()
+	p.applyHeader(r, header)
+	return r, nil
+}
+
+// parseHeader lê os campos de cabeçalho da linha para header. Rótulos cujo
+// valor está na coluna seguinte ("Paciente: \t FULANO") também são
+// reconhecidos. Retorna true para linhas de cabeçalho/rodapé, que não fazem
+// parte dos resultados. Vale a primeira ocorrência de cada campo.
+func (p *Profile) parseHeader(cells []string, header map[string]string, pending *string) bool {
+	for _, cell := range cells {
+		filled := *pending != ""
+		if filled {
+			if header[*pending] == "" {
+				header[*pending] = cell
+			}
+			*pending = ""
+		}
+
+		for _, field := range headerFields {
+			if field == "skip" {
+				continue
+			}
+			m := p.header[field].FindStringSubmatch(cell)
+			if m == nil {
+				continue
+			}
+			switch v := firstGroup(m); {
+			case v != "":
+				if header[field] == "" {
+					header[field] = v
+				}
+			case !filled && header[field] == "":
+				*pending = field
+			}
+		}
+	}
+	return p.header["skip"].MatchString(cells[0])
+}
+
+// applyHeader converte os campos lidos para o Report.
+func (p *Profile) applyHeader(r *Report, header map[string]string) {
+	r.CNES = header["cnes"]
+	if r.CNES == "" && len(p.Match.CNES) == 1 {
+		r.CNES = p.Match.CNES[0]
+	}
+	r.CRBM = header["crbm"]
+	r.PatientName = header["patient"]
+	r.Requester = header["requester"]
+	r.PatientCode = header["patient_code"]
+	// "17/11/1978(46 anos)"
+	r.BirthDate = reDate.FindString(header["birth_date"])
+	r.Age, _ = strconv.Atoi(header["age"])
+	r.Sex = header["sex"]
+	r.Convenio = strings.TrimRight(header["convenio"], ". ")
+	if v := header["collected_at"]; v != "" {
+		r.CollectedAt = p.parseDate(v)
+	}
+}
+
+func firstGroup(m []string) string {
+	for _, g := range m[1:] {
+		if g = strings.TrimSpace(g); g != "" {
+			return g
+		}
+	}
+	return ""
+}
+
+func markStarted(s *section) bool {
+	s.started = true
+	return true
+}
+
+func newSection(title string) *section {
+	return &section{title: strings.TrimSpace(title), inline: map[int]bool{}, lastRow: -1}
+}
+
+func (s *section) setMethod(m string) {
+	// "Método: X - Material: Y" traz outros campos na mesma linha
+	m, _, _ = strings.Cut(m, " - Material")
+	if m = strings.TrimSpace(m); m != "" && s.method == "" {
+		s.method = m
+	}
+}
+
+// addResult registra "Resultado: valor [unidade] [referência…]". Retorna
+// false se não houver valor.
+func (s *section) addResult(cells []string) bool {
+	cells = nonEmpty(cells)
+	if len(cells) == 0 {
+		return false
+	}
+
+	a := Analyte{Section: s.title, Name: s.title}
+	rest := cells[1:]
+	if m := reValue.FindStringSubmatch(cells[0]); m != nil && isUnit(m[2]) {
+		a.Value, a.Unit = strings.Join(strings.Fields(m[1]), " "), normalizeUnit(m[2])
+		if a.Unit == "" && len(rest) > 0 && isUnit(rest[0]) && !reValue.MatchString(rest[0]) {
+			a.Unit, rest = normalizeUnit(rest[0]), rest[1:]
+		}
+	} else {
+		// resultado textual ("Não reagente")
+		a.Value = cells[0]
+	}
+	a.Numeric = ParseNumber(a.Value)
+
+	s.inline[len(s.analytes)] = true
+	s.analytes = append(s.analytes, a)
+	if len(rest) > 0 {
+		s.refLines = append(s.refLines, strings.Join(rest, " "))
+	}
+	return true
+}
+
+// addRow reconhece linhas de tabela: nome, um ou dois valores (percentual e
+// absoluto no leucograma) e as faixas de referência na mesma ordem.
+func (s *section) addRow(cells []string) bool {
+	if len(cells) < 2 {
+		return false
+	}
+	name := strings.TrimSpace(strings.TrimRight(cells[0], ":. "))
+
+	var values []Analyte
+	i := 1
+	for ; i < len(cells) && len(values) < 2; i++ {
+		a, ok := rowValue(cells[i], len(values) == 0)
+		if !ok {
+			break
+		}
+		a.Section, a.Name = s.title, name
+		values = append(values, a)
+	}
+	if len(values) == 0 {
+		return false
+	}
+
+	ranges := cells[i:]
+	if len(ranges) == len(values) {
+		for j := range values {
+			values[j].ReferenceRange = ranges[j]
+		}
+	} else if len(ranges) > 0 {
+		values[0].ReferenceRange = strings.Join(ranges, " ")
+	}
+	s.analytes = append(s.analytes, values...)
+	s.lastRow = len(s.analytes) - len(values)
+	return true
+}
+
+// rowValue lê uma coluna de valor. Na tabela não há comparadores ("< 6" é
+// faixa de referência) e o segundo valor precisa de unidade, senão é faixa.
+func rowValue(cell string, first bool) (Analyte, bool) {
+	if m := reValue.FindStringSubmatch(cell); m != nil {
+		if strings.ContainsAny(m[1], "<>≤≥") || !isUnit(m[2]) || (!first && m[2] == "") {
+			return Analyte{}, false
+		}
+		return Analyte{Value: m[1], Unit: normalizeUnit(m[2]), Numeric: ParseNumber(m[1])}, true
+	}
+	if first && isQualitative(cell) {
+		return Analyte{Value: cell}, true
+	}
+	return Analyte{}, false
+}
+
+// finish aplica o método e o bloco de referência aos analitos da seção.
+func (s *section) finish() []Analyte {
+	ref := strings.Join(s.refLines, "\n")
+	for i := range s.analytes {
+		a := &s.analytes[i]
+		if a.Method == "" {
+			a.Method = s.method
+		}
+		// "Resultado:" ou exame de um analito só: a referência é o bloco todo
+		if (s.inline[i] || len(s.analytes) == 1) && a.ReferenceRange == "" {
+			a.ReferenceRange = ref
+		}
+	}
+	return s.analytes
+}
+
+// isTitle reconhece títulos de exame: colunas em maiúsculas, sem valores.
+// Uma coluna de "Valores de Referência" ao lado é aceita.
+func isTitle(cells []string) bool {
+	if !isTitleCell(cells[0]) {
+		return false
+	}
+	for _, c := range cells[1:] {
+		if !isTitleCell(c) && !isReferenceHeader([]string{c}) {
+			return false
+		}
+	}
+	return true
+}
+
+func titleCells(cells []string) []string {
+	var out []string
+	for _, c := range cells {
+		if isTitleCell(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// isTitleCell descarta linhas de endereço e registro ("CNES - 4308085",
+// "R 86, ESQ. …"): títulos têm mais letras que dígitos e nenhuma vírgula.
+func isTitleCell(c string) bool {
+	if !reTitle.MatchString(c) {
+		return false
+	}
+	letters, digits := 0, 0
+	for _, r := range c {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case unicode.IsLetter(r):
+			letters++
+		}
+	}
+	return letters >= 3 && letters > digits
+}
+
+// isReferenceHeader reconhece "Valor de referência", inclusive com as
+// quebras de sílaba de alguns geradores ("Valor de re ferên cia").
+func isReferenceHeader(cells []string) bool {
+	s := strings.ToLower(strings.ReplaceAll(strings.Join(cells, ""), " ", ""))
+	return strings.HasPrefix(s, "valor") && strings.Contains(s, "refer")
+}
+
+// isName aceita o nome do analito na primeira coluna da tabela.
+func (p *Profile) isName(c string) bool {
+	if c == "" || reValue.MatchString(c) || p.results["result"].MatchString(c) || isReferenceHeader([]string{c}) {
+		return false
+	}
+	return unicode.IsLetter([]rune(c)[0])
+}
+
+func isQualitative(c string) bool {
+	return qualitative[strings.TrimRight(strings.ToLower(strings.ReplaceAll(c, " ", "")), ".")]
+}
+
+// isUnit aceita o que vem depois do número: "mg/dL", "milhões/ mm³", "%".
+// Dígitos indicam outra coisa (faixa "a 99", data "/03/2025").
+func isUnit(s string) bool {
+	s = strings.TrimSpace(s)
+	return len(s) <= 20 && !strings.ContainsAny(s, "0123456789") && !strings.HasPrefix(s, "a ") && s != "a"
+}
+
+func normalizeUnit(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(strings.ReplaceAll(s, "/ ", "/"), " /", "/")
+}
+
+func splitCells(line string) []string {
+	return nonEmpty(strings.Split(line, "\t"))
+}
+
+func nonEmpty(cells []string) []string {
+	out := make([]string, 0, len(cells))
+	for _, c := range cells {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func joinLines(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "\n" + b
+}
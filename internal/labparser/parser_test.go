@@ -0,0 +1,111 @@
+package labparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "regrava os arquivos .golden.json")
+
+// Os laudos em testdata são o texto extraído (PDFExtractor) dos PDFs de
+// exemplo, com os dados do paciente substituídos.
+func TestParseGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".txt")
+		t.Run(name, func(t *testing.T) {
+			text, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := Parse(string(text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.Bytes()
+
+			golden := strings.TrimSuffix(input, ".txt") + ".golden.json"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (rode go test -update para gerar)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("resultado difere de %s (go test -update para regravar):\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestParseCountsAnalytes(t *testing.T) {
+	text, err := os.ReadFile(filepath.Join("testdata", "genesis.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Parse(string(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// o hemograma sozinho tem 34 linhas (diferencial em % e absoluto)
+	bySection := map[string]int{}
+	for _, a := range report.Analytes {
+		bySection[a.Section]++
+	}
+	if got := bySection["HEMOGRAMA"]; got != 34 {
+		t.Errorf("HEMOGRAMA: got %d analytes, want 34", got)
+	}
+	if got := len(report.Sections()); got != 11 {
+		t.Errorf("got %d sections, want 11: %v", got, report.Sections())
+	}
+}
+
+func TestRowValue(t *testing.T) {
+	tests := []struct {
+		cell   string
+		first  bool
+		want   string
+		wantOK bool
+	}{
+		{"92 mg/dL", true, "92", true},
+		{"4,5", true, "4,5", true},
+		{"Negativo", true, "Negativo", true},
+		{"38 %", false, "38", true},
+		// segunda coluna sem unidade é faixa de referência
+		{"38", false, "", false},
+		// comparadores indicam faixa, inclusive os de vários bytes
+		{"< 5 mg/dL", true, "", false},
+		{"> 5 mg/dL", true, "", false},
+		{"≤ 5 mg/dL", true, "", false},
+		{"≥5 mg/dL", true, "", false},
+	}
+	for _, tt := range tests {
+		a, ok := rowValue(tt.cell, tt.first)
+		if ok != tt.wantOK || a.Value != tt.want {
+			t.Errorf("rowValue(%q, %v) = %q, %v; want %q, %v", tt.cell, tt.first, a.Value, ok, tt.want, tt.wantOK)
+		}
+	}
+}
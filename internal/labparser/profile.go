@@ -0,0 +1,249 @@
+package labparser
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed profiles/*.yaml
+var bundled embed.FS
+
+// GenericProfile é o perfil usado quando nenhum laboratório casa com o laudo.
+const GenericProfile = "generic"
+
+// Campos reconhecidos em header e results.
+var (
+	headerFields = []string{
+		"cnes", "crbm", "patient", "requester", "patient_code",
+		"birth_date", "age", "sex", "convenio", "collected_at", "skip",
+	}
+	resultFields = []string{"exam", "result", "released", "method", "stop"}
+)
+
+// Profile descreve o layout de laudo de um laboratório parceiro. Os campos
+// não declarados vêm do perfil genérico.
+type Profile struct {
+	Name    string `yaml:"name"`
+	LabName string `yaml:"lab_name"`
+	Match   struct {
+		CNES []string `yaml:"cnes"`
+		Text []string `yaml:"text"`
+	} `yaml:"match"`
+	DateFormats []string          `yaml:"date_formats"`
+	Header      map[string]string `yaml:"header"`
+	Results     map[string]string `yaml:"results"`
+
+	header  map[string]*regexp.Regexp
+	results map[string]*regexp.Regexp
+}
+
+// Registry guarda os perfis e escolhe o de cada laudo.
+type Registry struct {
+	generic  *Profile
+	profiles []*Profile // sem o genérico, por nome
+}
+
+// LoadProfiles lê os perfis (*.yaml) de fsys. É obrigatório um perfil
+// "generic", que serve de base para os demais.
+func LoadProfiles(fsys fs.FS) (*Registry, error) {
+	return loadProfiles([]fs.FS{fsys})
+}
+
+// loadProfiles lê os diretórios em ordem; um perfil com o mesmo nome
+// substitui o anterior.
+func loadProfiles(dirs []fs.FS) (*Registry, error) {
+	byName := map[string]*Profile{}
+	for _, fsys := range dirs {
+		files, err := fs.Glob(fsys, "*.yaml")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return nil, err
+			}
+			var p Profile
+			if err := yaml.Unmarshal(data, &p); err != nil {
+				return nil, fmt.Errorf("labparser: profile %s: %w", file, err)
+			}
+			if p.Name == "" {
+				return nil, fmt.Errorf("labparser: profile %s: missing name", file)
+			}
+			byName[p.Name] = &p
+		}
+	}
+
+	generic := byName[GenericProfile]
+	if generic == nil {
+		return nil, errors.New("labparser: missing generic profile")
+	}
+	if err := generic.compile(nil); err != nil {
+		return nil, err
+	}
+
+	reg := &Registry{generic: generic}
+	for name, p := range byName {
+		if name == GenericProfile {
+			continue
+		}
+		if len(p.Match.CNES) == 0 && len(p.Match.Text) == 0 {
+			return nil, fmt.Errorf("labparser: profile %s: needs match.cnes or match.text", name)
+		}
+		if err := p.compile(generic); err != nil {
+			return nil, err
+		}
+		reg.profiles = append(reg.profiles, p)
+	}
+	sort.Slice(reg.profiles, func(i, j int) bool { return reg.profiles[i].Name < reg.profiles[j].Name })
+	return reg, nil
+}
+
+// compile valida e compila as regex, herdando de base o que faltar.
+func (p *Profile) compile(base *Profile) error {
+	var baseHeader, baseResults map[string]*regexp.Regexp
+	if base != nil {
+		baseHeader, baseResults = base.header, base.results
+		if len(p.DateFormats) == 0 {
+			p.DateFormats = base.DateFormats
+		}
+	}
+	var err error
+	if p.header, err = compileFields(p.Name, "header", p.Header, headerFields, baseHeader); err != nil {
+		return err
+	}
+	p.results, err = compileFields(p.Name, "results", p.Results, resultFields, baseResults)
+	return err
+}
+
+func compileFields(profile, section string, src map[string]string, known []string, base map[string]*regexp.Regexp) (map[string]*regexp.Regexp, error) {
+	for key := range src {
+		if !contains(known, key) {
+			return nil, fmt.Errorf("labparser: profile %s: unknown %s field %q", profile, section, key)
+		}
+	}
+	out := make(map[string]*regexp.Regexp, len(known))
+	for _, key := range known {
+		expr, ok := src[key]
+		if !ok {
+			if base == nil {
+				return nil, fmt.Errorf("labparser: profile %s: missing %s.%s", profile, section, key)
+			}
+			out[key] = base[key]
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("labparser: profile %s: %s.%s: %w", profile, section, key, err)
+		}
+		out[key] = re
+	}
+	return out, nil
+}
+
+// Select escolhe o perfil do laudo: primeiro pelo CNES do cabeçalho, depois
+// pelos trechos de match.text; sem correspondência, o genérico.
+func (r *Registry) Select(text string) *Profile {
+	if m := r.generic.header["cnes"].FindStringSubmatch(text); m != nil {
+		for _, p := range r.profiles {
+			if contains(p.Match.CNES, m[1]) {
+				return p
+			}
+		}
+	}
+	for _, p := range r.profiles {
+		if len(p.Match.Text) > 0 && containsAll(text, p.Match.Text) {
+			return p
+		}
+	}
+	return r.generic
+}
+
+// Parse interpreta o laudo com o perfil escolhido por Select.
+func (r *Registry) Parse(text string) (*Report, error) {
+	return r.Select(text).Parse(text)
+}
+
+// Profiles lista os nomes dos perfis carregados, incluindo o genérico.
+func (r *Registry) Profiles() []string {
+	names := []string{r.generic.Name}
+	for _, p := range r.profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func (p *Profile) parseDate(s string) *time.Time {
+	s = strings.Join(strings.Fields(s), " ")
+	for _, layout := range p.DateFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *Registry
+	defaultErr      error
+)
+
+// Default retorna os perfis embutidos mais os de LAB_PROFILES_DIR, se
+// definido. Um arquivo no diretório com o nome de um perfil embutido o
+// substitui; novos laboratórios entram só com um arquivo novo.
+func Default() (*Registry, error) {
+	defaultOnce.Do(func() {
+		sub, err := fs.Sub(bundled, "profiles")
+		if err != nil {
+			defaultErr = err
+			return
+		}
+		dirs := []fs.FS{sub}
+		if dir := os.Getenv("LAB_PROFILES_DIR"); dir != "" {
+			dirs = append(dirs, os.DirFS(dir))
+		}
+		defaultRegistry, defaultErr = loadProfiles(dirs)
+	})
+	return defaultRegistry, defaultErr
+}
+
+// Parse interpreta o laudo com os perfis de Default.
+func Parse(text string) (*Report, error) {
+	reg, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return reg.Parse(text)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAll compara sem diferença de espaços, que variam com o extrator.
+func containsAll(text string, parts []string) bool {
+	squash := func(s string) string { return strings.Join(strings.Fields(s), "") }
+	text = squash(text)
+	for _, part := range parts {
+		if !strings.Contains(text, squash(part)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package labparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSelectProfile(t *testing.T) {
+	reg, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"genesis.txt":    "genesis",    // CNES no cabeçalho
+		"excelencia.txt": "excelencia", // assinatura do layout
+	}
+	for file, want := range cases {
+		text, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := reg.Select(string(text)).Name; got != want {
+			t.Errorf("%s: got profile %q, want %q", file, got, want)
+		}
+	}
+	if got := reg.Select("CNES - 999\nGLICOSE\nResultado: 90 mg/dL").Name; got != GenericProfile {
+		t.Errorf("unknown lab: got profile %q, want generic", got)
+	}
+}
+
+// Um laboratório novo entra só com um arquivo de perfil; o que ele não
+// declara vem do genérico.
+func TestLoadProfilesInheritsGeneric(t *testing.T) {
+	generic, err := bundled.ReadFile("profiles/generic.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"generic.yaml": {Data: generic},
+		"novo.yaml": {Data: []byte(`
+name: novo
+lab_name: Laboratório Novo
+match:
+  cnes: ["1234567"]
+header:
+  patient: '^Cliente:\s*(.*)'
+results:
+  result: '^Valor obtido:\s*(.*)'
+`)},
+	}
+	reg, err := LoadProfiles(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := reg.Parse("CNES: 1234567\nCliente: FULANO\nColetado em: 02/05/2025\nGLICOSE\nValor obtido: 90 mg/dL\nLiberado em: 03/05/2025\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Profile != "novo" || report.LabName != "Laboratório Novo" || report.PatientName != "FULANO" {
+		t.Errorf("unexpected header: %+v", report)
+	}
+	if report.CollectedAt == nil || report.CollectedAt.Format("2006-01-02") != "2025-05-02" {
+		t.Errorf("collected_at not inherited from generic: %v", report.CollectedAt)
+	}
+	if len(report.Analytes) != 1 || report.Analytes[0].Value != "90" || report.Analytes[0].Unit != "mg/dL" {
+		t.Errorf("unexpected analytes: %+v", report.Analytes)
+	}
+}
+
+func TestLoadProfilesRejectsInvalid(t *testing.T) {
+	generic, err := bundled.ReadFile("profiles/generic.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"no match":      "name: x\nlab_name: X\n",
+		"unknown field": "name: x\nmatch: {cnes: ['1']}\nheader: {paciente: 'x'}\n",
+		"bad regex":     "name: x\nmatch: {cnes: ['1']}\nheader: {patient: '('}\n",
+	}
+	for name, data := range cases {
+		_, err := LoadProfiles(fstest.MapFS{
+			"generic.yaml": {Data: generic},
+			"x.yaml":       {Data: []byte(data)},
+		})
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := LoadProfiles(fstest.MapFS{}); err == nil {
+		t.Error("missing generic: expected error")
+	}
+}
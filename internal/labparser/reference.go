@@ -0,0 +1,313 @@
+package labparser
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"sonnda-api/internal/textfold"
+)
+
+// Range é uma linha da referência do laudo: limites, unidade e a quem se
+// aplica. Limites ausentes ficam nil ("Inferior a 200" só tem Max); limites
+// estritos ("Inferior a 200", "> 40") não fazem parte da faixa.
+type Range struct {
+	Label        string   `json:"label"` // linha original
+	Min          *float64 `json:"min,omitempty"`
+	Max          *float64 `json:"max,omitempty"`
+	MinExclusive bool     `json:"min_exclusive,omitempty"`
+	MaxExclusive bool     `json:"max_exclusive,omitempty"`
+	Unit         string   `json:"unit,omitempty"`
+	Sex          string   `json:"sex,omitempty"`     // "F", "M" ou vazio
+	AgeMin       *float64 `json:"age_min,omitempty"` // anos
+	AgeMax       *float64 `json:"age_max,omitempty"`
+	Condition    string   `json:"condition,omitempty"` // gestação, prematuro: fora da escolha automática
+	Normal       bool     `json:"normal,omitempty"`    // rotulada como normal ("70 a 99: Normal")
+	Critical     bool     `json:"critical,omitempty"`  // zona de valor crítico ("Risco de intoxicação")
+}
+
+// adultAge é a idade usada quando o laudo não informa a do paciente.
+const adultAge = 30
+
+// As linhas são comparadas sem acentos e sem espaços, porque o extrator
+// quebra palavras ("Adul tos aci ma de 20 anos", "1 a 12 me ses").
+var (
+	reHyphenBreak = regexp.MustCompile(`-\s*\n\s*`)
+
+	ageUnit    = `(anos?|mes(?:es)?|semanas?|dias?)`
+	reAgeSpan  = regexp.MustCompile(`(?i)(\d+)` + ageUnit + `?a(\d+)` + ageUnit)
+	reAgeAbove = regexp.MustCompile(`(?i)(?:>|≥|acimade|maiorque|maiorde)(\d+)` + ageUnit)
+	reAgeBelow = regexp.MustCompile(`(?i)(?:<|≤|ate|abaixode|menorque|menorde)(\d+)` + ageUnit)
+
+	num           = `(\d[\d.,]*)`
+	reSpanBetween = regexp.MustCompile(`(?i)entre` + num + `e` + num)
+	reSpan        = regexp.MustCompile(`(?i)` + num + `(?:a|-|ate)` + num)
+	reUpper       = regexp.MustCompile(`(?i)(<=|≤|ate|<|inferiora|menorque|menorde|abaixode)` + num)
+	reLower       = regexp.MustCompile(`(?i)(>=|≥|>|superiora|maiorque|maiorde|acimade)` + num)
+	reExact       = regexp.MustCompile(`^` + num)
+	reRangeUnit   = regexp.MustCompile(`^[^\d:;<>()]+`)
+)
+
+// ParseReference interpreta a referência do laudo, uma faixa por linha:
+// "70 a 99 mg/dL", "Inferior a 200", "Até 1,0", "Homens: 0,7 a 1,2 mg/dL",
+// "12 a 19 anos: 270-1132 pg/mL". Uma linha só com critérios ("Adultos
+// acima de 20 anos:") vale para as seguintes que não tenham os seus.
+// Linhas sem limite numérico ("Negativo") são ignoradas.
+func ParseReference(text string) []Range {
+	text = reHyphenBreak.ReplaceAllString(text, "")
+
+	var out []Range
+	var group Range // critérios da última linha sem valor
+	for _, line := range strings.Split(text, "\n") {
+		s := squashSpaces(textfold.RemoveAccents(line))
+		if s == "" {
+			continue
+		}
+		r := Range{Label: strings.TrimSpace(line)}
+		s = r.takeAge(s)
+		lower := strings.ToLower(s)
+		r.Sex = sexOf(lower)
+		r.Condition = conditionOf(lower)
+
+		if !r.takeLimits(s) {
+			if r.hasCriteria() {
+				group = r
+			}
+			continue
+		}
+		if !r.hasCriteria() {
+			r.Sex, r.AgeMin, r.AgeMax, r.Condition = group.Sex, group.AgeMin, group.AgeMax, group.Condition
+		}
+		r.Normal = strings.Contains(lower, "normal") || strings.Contains(lower, "normais")
+		r.Critical = strings.Contains(lower, "intoxica") || strings.Contains(lower, "critic") || strings.Contains(lower, "panico")
+		out = append(out, r)
+	}
+	return out
+}
+
+// takeAge lê o critério de idade e o remove da linha, para que "1 a 12
+// anos" não seja tomado como faixa de valores.
+func (r *Range) takeAge(s string) string {
+	if m := reAgeSpan.FindStringSubmatchIndex(s); m != nil {
+		minUnit, maxUnit := s[m[8]:m[9]], s[m[8]:m[9]]
+		if m[4] >= 0 {
+			minUnit = s[m[4]:m[5]]
+		}
+		r.AgeMin = ageYears(s[m[2]:m[3]], minUnit)
+		r.AgeMax = ageYears(s[m[6]:m[7]], maxUnit)
+		return s[:m[0]] + s[m[1]:]
+	}
+	if m := reAgeAbove.FindStringSubmatchIndex(s); m != nil {
+		r.AgeMin = ageYears(s[m[2]:m[3]], s[m[4]:m[5]])
+		return s[:m[0]] + s[m[1]:]
+	}
+	if m := reAgeBelow.FindStringSubmatchIndex(s); m != nil {
+		r.AgeMax = ageYears(s[m[2]:m[3]], s[m[4]:m[5]])
+		return s[:m[0]] + s[m[1]:]
+	}
+
+	lower := strings.ToLower(s)
+	switch {
+	case strings.Contains(lower, "adulto"):
+		r.AgeMin = ptr(18)
+	case strings.Contains(lower, "crianca") || strings.Contains(lower, "adolescente"):
+		r.AgeMax = ptr(17)
+	}
+	return s
+}
+
+// takeLimits lê os limites e a unidade que vem logo depois deles.
+func (r *Range) takeLimits(s string) bool {
+	var end int
+	if m := reSpanBetween.FindStringSubmatchIndex(s); m != nil {
+		r.Min, r.Max, end = parseLimit(s[m[2]:m[3]]), parseLimit(s[m[4]:m[5]]), m[1]
+	} else if m := reSpan.FindStringSubmatchIndex(s); m != nil {
+		r.Min, r.Max, end = parseLimit(s[m[2]:m[3]]), parseLimit(s[m[4]:m[5]]), m[1]
+	} else if m := reUpper.FindStringSubmatchIndex(s); m != nil {
+		r.Max, r.MaxExclusive, end = parseLimit(s[m[4]:m[5]]), strictBound(s[m[2]:m[3]]), m[1]
+	} else if m := reLower.FindStringSubmatchIndex(s); m != nil {
+		r.Min, r.MinExclusive, end = parseLimit(s[m[4]:m[5]]), strictBound(s[m[2]:m[3]]), m[1]
+	} else if m := reExact.FindStringSubmatchIndex(s); m != nil {
+		r.Min = parseLimit(s[m[2]:m[3]])
+		r.Max, end = r.Min, m[1]
+	}
+	if r.Min == nil && r.Max == nil {
+		return false
+	}
+	if unit := strings.TrimRight(reRangeUnit.FindString(s[end:]), ". "); isUnit(unit) && strings.ContainsAny(unit, "%/") || isUnitWord(unit) {
+		r.Unit = unit
+	}
+	return true
+}
+
+// strictBound informa se o operador exclui o próprio limite: "< 200" e
+// "Inferior a 200" sim; "≤ 200" e "Até 200" não.
+func strictBound(op string) bool {
+	switch strings.ToLower(op) {
+	case "<=", "≤", ">=", "≥", "ate":
+		return false
+	}
+	return true
+}
+
+// isUnitWord aceita unidades sem barra ("pg", "fl", "U").
+func isUnitWord(s string) bool {
+	return s != "" && len(s) <= 4 && isUnit(s) && strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) < 0
+}
+
+func (r *Range) hasCriteria() bool {
+	return r.Sex != "" || r.AgeMin != nil || r.AgeMax != nil || r.Condition != ""
+}
+
+func sexOf(s string) string {
+	switch {
+	case strings.Contains(s, "mulher") || strings.Contains(s, "feminin"):
+		return "F"
+	case strings.Contains(s, "homen") || strings.Contains(s, "masculin"):
+		return "M"
+	}
+	return ""
+}
+
+func conditionOf(s string) string {
+	switch {
+	case strings.Contains(s, "gestant") || strings.Contains(s, "gravidez") || strings.Contains(s, "trimestre"):
+		return "gestação"
+	case strings.Contains(s, "prematur"):
+		return "prematuro"
+	}
+	return ""
+}
+
+func ageYears(n, unit string) *float64 {
+	v := ParseNumber(n)
+	if v == nil {
+		return nil
+	}
+	switch unit = strings.ToLower(unit); {
+	case strings.HasPrefix(unit, "mes"):
+		*v /= 12
+	case strings.HasPrefix(unit, "semana"):
+		*v /= 52
+	case strings.HasPrefix(unit, "dia"):
+		*v /= 365
+	}
+	return v
+}
+
+func parseLimit(s string) *float64 {
+	return ParseNumber(strings.TrimRight(s, ".,"))
+}
+
+// SelectRange escolhe a faixa que vale para o paciente (sexo "F"/"M" ou
+// vazio; idade em anos, negativa se desconhecida). Entre as que se aplicam,
+// valem as específicas da idade e, delas, a rotulada como normal ou a
+// primeira. Sem sexo, faixas que só diferem por sexo são unidas: melhor não
+// marcar do que marcar errado.
+func SelectRange(ranges []Range, sex string, age float64) *Range {
+	if age < 0 {
+		age = adultAge
+	}
+	var candidates []Range
+	for _, r := range ranges {
+		if r.Critical || r.Condition != "" ||
+			(r.AgeMin != nil && age < *r.AgeMin) || (r.AgeMax != nil && age > *r.AgeMax) ||
+			(sex != "" && r.Sex != "" && r.Sex != sex) {
+			continue
+		}
+		candidates = append(candidates, r)
+	}
+	candidates = preferAge(candidates)
+	for _, r := range candidates {
+		if r.Normal {
+			return &r
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	first := candidates[0]
+	if sex != "" || first.Sex == "" {
+		return &first
+	}
+	for _, r := range candidates[1:] {
+		if r.Sex == "" || r.Sex == first.Sex {
+			continue
+		}
+		if lo := lowest(first.Min, r.Min); lo != first.Min {
+			first.Min, first.MinExclusive = lo, r.MinExclusive
+		}
+		if hi := highest(first.Max, r.Max); hi != first.Max {
+			first.Max, first.MaxExclusive = hi, r.MaxExclusive
+		}
+		first.Sex = ""
+	}
+	return &first
+}
+
+// preferAge fica com as faixas por idade quando alguma se aplica: "Crianças
+// de 1 a 3 anos" vale mais que "Homens" para um menino de 2 anos.
+func preferAge(ranges []Range) []Range {
+	var out []Range
+	for _, r := range ranges {
+		if r.AgeMin != nil || r.AgeMax != nil {
+			out = append(out, r)
+		}
+	}
+	if len(out) == 0 {
+		return ranges
+	}
+	return out
+}
+
+// Compare diz se v está abaixo (-1), dentro (0) ou acima (1) da faixa. Um
+// limite estrito fica fora dela: 200 está acima de "Inferior a 200".
+func (r *Range) Compare(v float64) int {
+	switch {
+	case r.Min != nil && (v < *r.Min || r.MinExclusive && v == *r.Min):
+		return -1
+	case r.Max != nil && (v > *r.Max || r.MaxExclusive && v == *r.Max):
+		return 1
+	}
+	return 0
+}
+
+// InCriticalZone informa se v cai numa faixa de valor crítico da
+// referência ("Risco de intoxicação: Superior a 100").
+func InCriticalZone(ranges []Range, v float64) bool {
+	for i := range ranges {
+		if ranges[i].Critical && ranges[i].Compare(v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func lowest(a, b *float64) *float64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	if *b < *a {
+		return b
+	}
+	return a
+}
+
+func highest(a, b *float64) *float64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	if *b > *a {
+		return b
+	}
+	return a
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func squashSpaces(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
@@ -0,0 +1,145 @@
+package labparser
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSelectRange(t *testing.T) {
+	cases := []struct {
+		ref      string
+		sex      string
+		age      float64
+		min, max string
+		unit     string
+	}{
+		{"70 a 99 mg/dL", "", -1, "70", "99", "mg/dL"},
+		{"Inferior a 200", "", -1, "_", "200", ""},
+		{"Até 1,0 mg/dL", "", -1, "_", "1", "mg/dL"},
+		{"Até 10.000 /mL", "", -1, "_", "10000", "/mL"},
+		{"Superior a 40 mg/dL", "", -1, "40", "_", "mg/dL"},
+		{"0", "", -1, "0", "0", ""},
+		// o rótulo "Normal" vence as demais linhas
+		{"70 a 99 mg/dL: Normal.\n100 a 125 mg/dL: Glicemia jejum alterada.\n> 125 mg/dL: Possível Diabetes.", "", -1, "70", "99", "mg/dL"},
+		{"Deficiência....: Infe-\nrior a 20,0 ng/mL\nValores normais para a população geral.: En-\ntre 20,0 e 60,0 ng/mL", "", 40, "20", "60", "ng/mL"},
+		// por sexo; sem sexo, a união das faixas
+		{"Homens......0,7 a 1,2 mg/dL\nMulheres.....0,5 a 1,1 mg/dL\nCrianças de 01 a 03 anos 0,3 a 0,7 mg/dL", "F", 55, "0.5", "1.1", "mg/dL"},
+		{"Homens......0,7 a 1,2 mg/dL\nMulheres.....0,5 a 1,1 mg/dL\nCrianças de 01 a 03 anos 0,3 a 0,7 mg/dL", "", 55, "0.5", "1.2", "mg/dL"},
+		{"Homens......0,7 a 1,2 mg/dL\nMulheres.....0,5 a 1,1 mg/dL\nCrianças de 01 a 03 anos 0,3 a 0,7 mg/dL", "M", 2, "0.3", "0.7", "mg/dL"},
+		// por idade, com palavras quebradas pelo extrator
+		{"0 a 1 mês: 187-1866 pg/mL\n1 a 12 me ses: 168-1675 pg/mL\n1 a 12 anos: 354-1599 pg/mL\n> 19 anos: 245-985 pg/mL", "", 55, "245", "985", "pg/mL"},
+		{"0 a 1 mês: 187-1866 pg/mL\n1 a 12 me ses: 168-1675 pg/mL\n1 a 12 anos: 354-1599 pg/mL\n> 19 anos: 245-985 pg/mL", "", 0.5, "168", "1675", "pg/mL"},
+		{"Adul tos aci ma de 20 anos: < 190 mg/dL\nCrianças e adolescentes: < 170 mg/dL", "", 10, "_", "170", "mg/dL"},
+		{"Até 5 anos: 3 a 10\nAdultos: 5 a 15", "", 3, "3", "10", ""},
+		{"Até 5 anos: 3 a 10\nAdultos: 5 a 15", "", 40, "5", "15", ""},
+		// linha só com critério vale para as seguintes
+		{"Adul tos aci ma de 20 anos:\nCom jejum: < 150 mg/dL\nSem jejum: < 175 mg/dL\n0 a 9 anos < 75 mg/dL < 85 mg/dL", "", 5, "_", "75", "mg/dL"},
+		// gestação fica de fora
+		{"Gravidez:\n1° trimestre: 0,05 a 3,70 µUI/mL\nAdultos: 0,38 a 5,33 µUI/mL", "F", 30, "0.38", "5.33", "µUI/mL"},
+	}
+	for _, c := range cases {
+		r := SelectRange(ParseReference(c.ref), c.sex, c.age)
+		if r == nil {
+			t.Errorf("%q: no range", c.ref)
+			continue
+		}
+		if got := fmtLimit(r.Min); got != c.min {
+			t.Errorf("%q (%s, %v): min %s, want %s", c.ref, c.sex, c.age, got, c.min)
+		}
+		if got := fmtLimit(r.Max); got != c.max {
+			t.Errorf("%q (%s, %v): max %s, want %s", c.ref, c.sex, c.age, got, c.max)
+		}
+		if r.Unit != c.unit {
+			t.Errorf("%q: unit %q, want %q", c.ref, r.Unit, c.unit)
+		}
+	}
+
+	for _, ref := range []string{"Negativo", "Não há valores de referência definidos para este exame.", "ml"} {
+		if r := SelectRange(ParseReference(ref), "", -1); r != nil {
+			t.Errorf("%q: got range %+v", ref, r)
+		}
+	}
+}
+
+func TestAgeBelow(t *testing.T) {
+	r := ParseReference("Até 5 anos: 3 a 10")
+	if len(r) != 1 || fmtLimit(r[0].AgeMax) != "5" || r[0].AgeMin != nil {
+		t.Fatalf("got %+v, want AgeMax 5", r)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		ref   string
+		value float64
+		want  int
+	}{
+		{"70 a 99 mg/dL", 70, 0},
+		{"70 a 99 mg/dL", 99, 0},
+		{"70 a 99 mg/dL", 99.5, 1},
+		{"Inferior a 200", 199, 0},
+		{"Inferior a 200", 200, 1},
+		{"< 150 mg/dL", 150, 1},
+		{"≤ 150 mg/dL", 150, 0},
+		{"Até 1,0 mg/dL", 1, 0},
+		{"Superior a 40 mg/dL", 40, -1},
+		{"Superior a 40 mg/dL", 41, 0},
+		{"> 40 mg/dL", 40, -1},
+		{"≥ 40 mg/dL", 40, 0},
+	}
+	for _, c := range cases {
+		r := SelectRange(ParseReference(c.ref), "", -1)
+		if r == nil {
+			t.Errorf("%q: no range", c.ref)
+			continue
+		}
+		if got := r.Compare(c.value); got != c.want {
+			t.Errorf("%q: Compare(%v) = %d, want %d", c.ref, c.value, got, c.want)
+		}
+	}
+}
+
+func TestInCriticalZone(t *testing.T) {
+	ranges := ParseReference("Valores normais: Entre 20,0 e 60,0 ng/mL\nRisco de intoxicação...: Supe-\nrior a 100,0 ng/mL")
+	if InCriticalZone(ranges, 80) || !InCriticalZone(ranges, 150) {
+		t.Error("critical zone from reference text not detected")
+	}
+}
+
+func TestIsCritical(t *testing.T) {
+	c, err := DefaultCriticalValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		section, name, unit string
+		value               float64
+		want                bool
+	}{
+		{"POTÁSSIO SÉRICO", "POTÁSSIO SÉRICO", "mmol/L", 7.1, true},
+		{"POTÁSSIO SÉRICO", "POTÁSSIO SÉRICO", "mmol/L", 4.4, false},
+		{"GLICOSE", "GLICOSE", "mg/dL", 32, true},
+		{"HEMOGRAMA", "Plaquetas", "/mm³", 12000, true},
+		{"HEMOGRAMA", "Hemoglobina", "g/dL", 5.9, true},
+		{"HEMOGRAMA", "Leucócitos", "/µL", 1500, true},
+		{"SUMÁRIO DE URINA", "Leucócitos", "/mL", 250, false}, // outra unidade
+		{"SUMÁRIO DE URINA", "Leucócitos", "/µL", 10, false},  // urina, mesmo em /mm³
+		{"URINA TIPO I", "Leucócitos", "/mm³", 5, false},
+		{"HEMOGRAMA", "Concentração de Hemoglobina Corpuscular Média", "g/dL", 33.6, false},
+		{"HEMOGRAMA", "Hemoglobina Corpuscular Média", "g/dL", 29, false},
+		{"HEMOGLOBINA GLICADA", "Hemoglobina glicada", "%", 14, false},
+		{"", "Leucócitos na urina", "/mm³", 12, false},
+	}
+	for _, tc := range cases {
+		if got := c.IsCritical(tc.section, tc.name, tc.unit, tc.value); got != tc.want {
+			t.Errorf("%s / %s %v %s: got %v, want %v", tc.section, tc.name, tc.value, tc.unit, got, tc.want)
+		}
+	}
+}
+
+func fmtLimit(v *float64) string {
+	if v == nil {
+		return "_"
+	}
+	return fmt.Sprint(*v)
+}
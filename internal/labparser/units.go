@@ -0,0 +1,121 @@
+package labparser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sonnda-api/internal/textfold"
+)
+
+var (
+	reThousands = regexp.MustCompile(`^[1-9]\d{0,2}(\.\d{3})+$`)           // 5.400, 287.000
+	reDecimal   = regexp.MustCompile(`^([1-9]\d{0,2}(\.\d{3})*|\d+),\d+$`) // 4,75, 1.234,5
+	rePlain     = regexp.MustCompile(`^\d+(\.\d+)?$`)                      // 154, 6.5, 0.25
+)
+
+// ParseNumber interpreta números no formato brasileiro: vírgula decimal e
+// ponto de milhar ("4,75", "5.400", "1.234,5"). Só com ponto, é milhar
+// quando seguido de grupos de três dígitos ("287.000") e decimal nos demais
+// casos ("6.5", "0.500"). Valores com comparador ("< 6") ou textuais
+// retornam nil.
+func ParseNumber(s string) *float64 {
+	s = strings.TrimSpace(s)
+	switch {
+	case reThousands.MatchString(s):
+		s = strings.ReplaceAll(s, ".", "")
+	case reDecimal.MatchString(s):
+		s = strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), ",", ".")
+	case !rePlain.MatchString(s):
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+// unitAliases leva as grafias dos laudos à unidade canônica. A chave é a
+// unidade sem espaços, sem acentos, em minúsculas, com "³" → "3" e "µ" → "u".
+var unitAliases = map[string]string{
+	"mg/dl": "mg/dL", "g/dl": "g/dL", "g/l": "g/L", "mg/l": "mg/L",
+	"ug/dl": "µg/dL", "ng/ml": "ng/mL", "ng/dl": "ng/dL", "pg/ml": "pg/mL",
+	"mmol/l": "mmol/L", "umol/l": "µmol/L", "meq/l": "mEq/L",
+	"ui/l": "UI/L", "u/l": "U/L", "ui/ml": "UI/mL",
+	"uui/ml": "µUI/mL", "mui/l": "µUI/mL", // mUI/L e µUI/mL são a mesma grandeza
+	"/mm3": "/mm³", "mm3": "/mm³", "/ul": "/mm³", "cel/mm3": "/mm³", "celulas/mm3": "/mm³",
+	"mil/mm3": "mil/mm³", "10^3/ul": "mil/mm³", "103/ul": "mil/mm³", "x10^3/ul": "mil/mm³",
+	"milhoes/mm3": "milhões/mm³", "10^6/ul": "milhões/mm³", "106/ul": "milhões/mm³", "x10^6/ul": "milhões/mm³",
+	"fl": "fL", "/fl": "fL", "pg": "pg", "%": "%",
+	"ml": "mL", "/ml": "/mL",
+}
+
+// CanonicalUnit padroniza a grafia da unidade ("mg/dl" → "mg/dL",
+// "mil/ mm3" → "mil/mm³"). Unidades desconhecidas voltam como vieram.
+func CanonicalUnit(s string) string {
+	if canonical, ok := unitAliases[unitKey(s)]; ok {
+		return canonical
+	}
+	return normalizeUnit(s)
+}
+
+// SameUnit compara unidades pela forma canônica.
+func SameUnit(a, b string) bool {
+	return unitKey(CanonicalUnit(a)) == unitKey(CanonicalUnit(b))
+}
+
+func unitKey(s string) string {
+	s = strings.ToLower(squashSpaces(textfold.RemoveAccents(s)))
+	return strings.NewReplacer("³", "3", "µ", "u", "μ", "u").Replace(s)
+}
+
+// unitRule é a unidade preferida de um analito e os fatores para chegar nela.
+type unitRule struct {
+	match   []string           // trechos do nome, sem acentos e espaços
+	unit    string             // unidade preferida
+	factors map[string]float64 // unidade canônica → multiplicador
+	max     float64            // acima disso o ponto era decimal, não milhar
+}
+
+var unitRules = []unitRule{
+	{match: []string{"glicose", "glicemia"}, unit: "mg/dL", factors: map[string]float64{"mmol/L": 18.016}},
+	{match: []string{"colesterol"}, unit: "mg/dL", factors: map[string]float64{"mmol/L": 38.67}},
+	{match: []string{"triglicerides", "triglicerideos"}, unit: "mg/dL", factors: map[string]float64{"mmol/L": 88.57}},
+	{match: []string{"creatinina"}, unit: "mg/dL", factors: map[string]float64{"µmol/L": 1 / 88.4}},
+	{match: []string{"ureia"}, unit: "mg/dL", factors: map[string]float64{"mmol/L": 6.006}},
+	{match: []string{"potassio", "sodio", "cloreto"}, unit: "mmol/L", factors: map[string]float64{"mEq/L": 1}},
+	{match: []string{"hemoglobina"}, unit: "g/dL", factors: map[string]float64{"g/L": 0.1, "mmol/L": 1.611}},
+	{match: []string{"leucocitos", "plaquetas"}, unit: "/mm³", factors: map[string]float64{"mil/mm³": 1000}},
+	{match: []string{"densidade"}, max: 1.1},
+}
+
+// Normalize leva o valor do analito à unidade preferida (glicose em mmol/L
+// → mg/dL; plaquetas em mil/mm³ → /mm³) e corrige a densidade urinária
+// lida como milhar ("1.010"). Sem regra, só a grafia da unidade muda.
+func Normalize(name string, value float64, unit string) (float64, string) {
+	unit = CanonicalUnit(unit)
+	rule := findUnitRule(name)
+	if rule == nil {
+		return value, unit
+	}
+	if rule.max > 0 && value > rule.max && value/1000 <= rule.max {
+		value /= 1000
+	}
+	if factor, ok := rule.factors[unit]; ok {
+		return value * factor, rule.unit
+	}
+	return value, unit
+}
+
+func findUnitRule(name string) *unitRule {
+	key := strings.ToLower(squashSpaces(textfold.RemoveAccents(name)))
+	for i := range unitRules {
+		for _, m := range unitRules[i].match {
+			if strings.Contains(key, m) {
+				return &unitRules[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package labparser
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseNumber(t *testing.T) {
+	cases := map[string]float64{
+		"4,75":      4.75,
+		"5.400":     5400,
+		"287.000":   287000,
+		"1.234,5":   1234.5,
+		"154":       154,
+		"6.5":       6.5,
+		"0.500":     0.5,
+		"0,38":      0.38,
+		"10.000":    10000,
+		"1.000.000": 1000000,
+	}
+	for in, want := range cases {
+		got := ParseNumber(in)
+		if got == nil || *got != want {
+			t.Errorf("ParseNumber(%q) = %v, want %v", in, got, want)
+		}
+	}
+	for _, in := range []string{"< 6", "Negativo", "", "4,5,6", "1.23.4"} {
+		if got := ParseNumber(in); got != nil {
+			t.Errorf("ParseNumber(%q) = %v, want nil", in, *got)
+		}
+	}
+}
+
+func TestCanonicalUnit(t *testing.T) {
+	cases := map[string]string{
+		"mg/dl":       "mg/dL",
+		"MG/DL":       "mg/dL",
+		"mil/ mm3":    "mil/mm³",
+		"10^3/µL":     "mil/mm³",
+		"milhões/mm³": "milhões/mm³",
+		"/uL":         "/mm³",
+		"uUI/mL":      "µUI/mL",
+		"mEq/l":       "mEq/L",
+		"seg":         "seg",
+	}
+	for in, want := range cases {
+		if got := CanonicalUnit(in); got != want {
+			t.Errorf("CanonicalUnit(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name, unit string
+		value      float64
+		want       float64
+		wantUnit   string
+	}{
+		{"GLICOSE", "mmol/L", 5.5, 99.09, "mg/dL"},
+		{"Glicose", "mg/dl", 90, 90, "mg/dL"},
+		{"Colesterol total", "mmol/L", 5, 193.35, "mg/dL"},
+		{"Triglicerides", "mmol/L", 1.7, 150.57, "mg/dL"},
+		{"CREA TININA", "µmol/L", 88.4, 1, "mg/dL"},
+		{"Potássio", "mEq/L", 4.4, 4.4, "mmol/L"},
+		{"Plaquetas", "mil/mm³", 287, 287000, "/mm³"},
+		{"Leucócitos", "10^3/uL", 5.4, 5400, "/mm³"},
+		{"Densidade", "", 1010, 1.01, ""}, // "1.010" lido como milhar
+		{"Densidade", "", 1.02, 1.02, ""},
+		{"Hemoglobina glicada", "%", 9.6, 9.6, "%"},
+	}
+	for _, c := range cases {
+		got, unit := Normalize(c.name, c.value, c.unit)
+		if math.Abs(got-c.want) > 0.01 || unit != c.wantUnit {
+			t.Errorf("Normalize(%q, %v, %q) = %v %q, want %v %q", c.name, c.value, c.unit, got, unit, c.want, c.wantUnit)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"context"
+	"os"
+)
+
+// Message é um e-mail em texto simples.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envia e-mails transacionais (reset de senha, verificação, convites).
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewFromEnv escolhe a implementação pelo MAIL_DRIVER: "smtp" usa as variáveis
+// SMTP_*; qualquer outro valor grava as mensagens em MAIL_LOG_PATH (ou no log).
+func NewFromEnv() Mailer {
+	if os.Getenv("MAIL_DRIVER") == "smtp" {
+		return NewSMTPMailer(
+			os.Getenv("SMTP_HOST"),
+			os.Getenv("SMTP_PORT"),
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("MAIL_FROM"),
+		)
+	}
+	return NewLogMailer(os.Getenv("MAIL_LOG_PATH"))
+}
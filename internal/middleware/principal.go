@@ -0,0 +1,80 @@
+// internal/middleware/principal.go
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"sonnda-api/internal/professional"
+	"sonnda-api/internal/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Principal é o usuário autenticado da requisição, extraído do access token.
+type Principal struct {
+	UserID     uint
+	Email      string
+	Role       user.Role
+	Profession professional.Profession // vazio quando o usuário não tem profissão
+	TokenID    string                  // jti do access token
+	ExpiresAt  time.Time
+}
+
+// Profile é a chave usada na política de permissões. Na equipe clínica (role
+// DOCTOR) a profissão, quando informada, define o perfil (enfermeiro, acs...);
+// admins e pacientes usam sempre a role.
+func (p *Principal) Profile() string {
+	if p.Role == user.RoleDoctor && p.Profession != "" {
+		return string(p.Profession)
+	}
+	return string(p.Role)
+}
+
+const principalKey = "principal"
+
+type principalCtxKey struct{}
+
+// SetPrincipal publica o principal no contexto do gin e no context.Context da
+// requisição, para que services que recebem só o ctx também o enxerguem.
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), principalCtxKey{}, p))
+}
+
+// CurrentPrincipal retorna o principal autenticado da requisição.
+func CurrentPrincipal(c *gin.Context) (*Principal, bool) {
+	v, exists := c.Get(principalKey)
+	if !exists {
+		return nil, false
+	}
+	p, ok := v.(*Principal)
+	return p, ok
+}
+
+// PrincipalFromContext retorna o principal a partir de um context.Context.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	if c, ok := ctx.(*gin.Context); ok {
+		return CurrentPrincipal(c)
+	}
+	p, ok := ctx.Value(principalCtxKey{}).(*Principal)
+	return p, ok
+}
+
+// GetUserRole retorna a role do usuário autenticado do contexto
+func GetUserRole(c *gin.Context) (user.Role, bool) {
+	p, ok := CurrentPrincipal(c)
+	if !ok {
+		return "", false
+	}
+	return p.Role, true
+}
+
+// GetUserID retorna o ID do usuário autenticado do contexto
+func GetUserID(c *gin.Context) (uint, bool) {
+	p, ok := CurrentPrincipal(c)
+	if !ok {
+		return 0, false
+	}
+	return p.UserID, true
+}
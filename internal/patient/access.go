@@ -0,0 +1,163 @@
+package patient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sonnda-api/internal/middleware"
+	"sonnda-api/internal/rbac"
+	"sonnda-api/internal/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	patientIDKey     = "patientID"
+	authorizationKey = "patientAuthorization"
+)
+
+// AccessGuard aplica o consentimento do paciente (LGPD): só acessam os dados
+// o próprio paciente, admins e quem tiver uma autorização aprovada e vigente.
+type AccessGuard struct {
+	repo   Repository
+	policy *rbac.Policy
+}
+
+func NewAccessGuard(repo Repository, policy *rbac.Policy) *AccessGuard {
+	return &AccessGuard{repo: repo, policy: policy}
+}
+
+// CanAccess informa se o principal pode acessar os dados do paciente.
+// Terceiros precisam, além do consentimento, das permissões informadas.
+// A autorização retornada traz o escopo do acesso; é nil quando o acesso
+// não depende de consentimento (o próprio paciente ou admin).
+func (g *AccessGuard) CanAccess(ctx context.Context, p *middleware.Principal, patientID uint, perms ...rbac.Permission) (bool, *Authorization, error) {
+	if p.UserID == patientID {
+		return true, nil, nil
+	}
+	for _, perm := range perms {
+		allowed, err := g.policy.Allows(ctx, p.Profile(), perm)
+		if err != nil || !allowed {
+			return false, nil, err
+		}
+	}
+	if p.Role == user.RoleAdmin {
+		return true, nil, nil
+	}
+	auths, err := g.repo.FindActiveAuthorizations(ctx, p.UserID, patientID)
+	if err != nil {
+		return false, nil, err
+	}
+	auth := mergeAuthorizations(auths, time.Now().UTC())
+	if auth == nil {
+		return false, nil, nil
+	}
+	return true, auth, nil
+}
+
+// mergeAuthorizations junta o escopo das autorizações vigentes: um acesso de
+// emergência (somente leitura) não pode esconder um consentimento aprovado
+// com escrita. Parte da mais recente; só é somente leitura se todas forem, e
+// os tipos de registro são a união (lista vazia = todos).
+func mergeAuthorizations(auths []Authorization, now time.Time) *Authorization {
+	var merged *Authorization
+	for i := range auths {
+		a := &auths[i]
+		if !a.IsActive(now) {
+			continue
+		}
+		if merged == nil {
+			m := *a
+			m.RecordTypes = append([]MedicalRecordType(nil), a.RecordTypes...)
+			merged = &m
+			continue
+		}
+		merged.ReadOnly = merged.ReadOnly && a.ReadOnly
+		if len(merged.RecordTypes) == 0 || len(a.RecordTypes) == 0 {
+			merged.RecordTypes = nil
+			continue
+		}
+		for _, t := range a.RecordTypes {
+			if !merged.CoversRecordType(t) {
+				merged.RecordTypes = append(merged.RecordTypes, t)
+			}
+		}
+	}
+	return merged
+}
+
+// Require protege rotas com o id do paciente no parâmetro informado
+// (ex.: "id" em /patients/:id/...). O id validado fica disponível via
+// PatientIDFromContext e o escopo do consentimento via
+// AuthorizationFromContext. Autorizações somente leitura bloqueiam métodos
+// de escrita.
+func (g *AccessGuard) Require(param string, perms ...rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := middleware.CurrentPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		id, err := strconv.ParseUint(c.Param(param), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid_patient_id"})
+			return
+		}
+
+		allowed, auth, err := g.CanAccess(c, p, uint(id), perms...)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "patient_access_denied"})
+			return
+		}
+
+		if auth != nil && auth.ReadOnly && !isReadMethod(c.Request.Method) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "read_only_authorization"})
+			return
+		}
+
+		c.Set(patientIDKey, uint(id))
+		if auth != nil {
+			c.Set(authorizationKey, auth)
+		}
+		c.Next()
+	}
+}
+
+// PatientIDFromContext retorna o id do paciente validado por AccessGuard.Require.
+func PatientIDFromContext(c *gin.Context) (uint, bool) {
+	v, exists := c.Get(patientIDKey)
+	if !exists {
+		return 0, false
+	}
+	id, ok := v.(uint)
+	return id, ok
+}
+
+// AuthorizationFromContext retorna a autorização usada no acesso atual; nil
+// quando o acesso é do próprio paciente ou de um admin.
+func AuthorizationFromContext(c *gin.Context) *Authorization {
+	v, exists := c.Get(authorizationKey)
+	if !exists {
+		return nil
+	}
+	auth, _ := v.(*Authorization)
+	return auth
+}
+
+// ScopeCovers informa se o acesso atual (via AccessGuard.Require) alcança o
+// tipo de registro.
+func ScopeCovers(c *gin.Context, t MedicalRecordType) bool {
+	auth := AuthorizationFromContext(c)
+	return auth == nil || auth.CoversRecordType(t)
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
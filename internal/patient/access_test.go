@@ -0,0 +1,162 @@
+package patient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sonnda-api/internal/middleware"
+	"sonnda-api/internal/professional"
+	"sonnda-api/internal/rbac"
+	"sonnda-api/internal/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeRepo implementa só o que os testes do pacote usam.
+type fakeRepo struct {
+	Repository
+	auths    []Authorization
+	problems []MedicalRecord
+}
+
+func (r *fakeRepo) FindActiveAuthorizations(ctx context.Context, userID, patientID uint) ([]Authorization, error) {
+	var out []Authorization
+	for _, a := range r.auths {
+		if a.UserID == userID && a.PatientID == patientID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+type fakeGrants []rbac.RoleGrant
+
+func (g fakeGrants) ListGrants(ctx context.Context) ([]rbac.RoleGrant, error) { return g, nil }
+func (g fakeGrants) Grant(ctx context.Context, rg *rbac.RoleGrant) error      { return nil }
+func (g fakeGrants) Revoke(ctx context.Context, profile string, perm rbac.Permission) error {
+	return nil
+}
+func (g fakeGrants) SeedDefaults(ctx context.Context) error { return nil }
+
+var doctor = &middleware.Principal{UserID: 2, Role: user.RoleDoctor, Profession: professional.ProfessionMedico}
+
+func newTestGuard(auths ...Authorization) *AccessGuard {
+	policy := rbac.NewPolicy(fakeGrants{
+		{Profile: string(professional.ProfessionMedico), Permission: rbac.PermRecordRead},
+		{Profile: string(professional.ProfessionMedico), Permission: rbac.PermRecordWrite},
+		{Profile: string(user.RoleAdmin), Permission: rbac.PermRecordRead},
+		{Profile: string(user.RoleAdmin), Permission: rbac.PermRecordWrite},
+	}, time.Minute)
+	return NewAccessGuard(&fakeRepo{auths: auths}, policy)
+}
+
+// serve executa Require numa rota /patients/:id com o principal informado.
+func serve(g *AccessGuard, p *middleware.Principal, method, path string, perms ...rbac.Permission) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if p != nil {
+			middleware.SetPrincipal(c, p)
+		}
+	})
+	r.Handle(method, "/patients/:id", g.Require("id", perms...), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestAccessGuardMergesActiveGrants(t *testing.T) {
+	now := time.Now().UTC()
+	inFourHours := now.Add(4 * time.Hour)
+	approved := Authorization{
+		ID: 1, UserID: 2, PatientID: 1, Status: AuthApproved,
+		RequestedAt: now.Add(-48 * time.Hour),
+		RecordTypes: []MedicalRecordType{RecordTypeNote},
+	}
+	emergency := Authorization{
+		ID: 2, UserID: 2, PatientID: 1, Status: AuthEmergency,
+		RequestedAt: now.Add(-time.Hour), ExpiresAt: &inFourHours, ReadOnly: true,
+		RecordTypes: []MedicalRecordType{RecordTypeExam},
+	}
+	// o repositório devolve da mais recente para a mais antiga
+	g := newTestGuard(emergency, approved)
+
+	if w := serve(g, doctor, http.MethodPost, "/patients/1", rbac.PermRecordWrite); w.Code != http.StatusOK {
+		t.Errorf("write with approved grant shadowed by emergency: got %d, want 200", w.Code)
+	}
+
+	_, auth, err := g.CanAccess(context.Background(), doctor, 1, rbac.PermRecordRead)
+	if err != nil || auth == nil {
+		t.Fatalf("CanAccess: auth %v, err %v", auth, err)
+	}
+	if auth.ReadOnly {
+		t.Error("merged authorization is read-only")
+	}
+	for _, rt := range []MedicalRecordType{RecordTypeNote, RecordTypeExam} {
+		if !auth.CoversRecordType(rt) {
+			t.Errorf("merged authorization does not cover %s", rt)
+		}
+	}
+	if emergency.RecordTypes[0] != RecordTypeExam || len(emergency.RecordTypes) != 1 {
+		t.Errorf("merge changed the source grant: %v", emergency.RecordTypes)
+	}
+}
+
+func TestAccessGuardRequire(t *testing.T) {
+	now := time.Now().UTC()
+	yesterday := now.Add(-24 * time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+	grant := func(status AuthStatus, expiresAt *time.Time, readOnly bool) Authorization {
+		a := Authorization{UserID: 2, PatientID: 1, Status: status, RequestedAt: now.Add(-48 * time.Hour), ExpiresAt: expiresAt, ReadOnly: readOnly}
+		if status == AuthRevoked {
+			a.RevokedAt = &yesterday
+		}
+		return a
+	}
+	nurse := &middleware.Principal{UserID: 3, Role: user.RoleDoctor, Profession: professional.ProfessionEnfermeiro}
+	admin := &middleware.Principal{UserID: 9, Role: user.RoleAdmin}
+	patientSelf := &middleware.Principal{UserID: 1, Role: user.RolePatient}
+
+	tests := []struct {
+		name      string
+		principal *middleware.Principal
+		auths     []Authorization
+		method    string
+		path      string
+		perm      rbac.Permission
+		want      int
+		wantErr   string
+	}{
+		{"o próprio paciente", patientSelf, nil, http.MethodPost, "/patients/1", rbac.PermRecordWrite, http.StatusOK, ""},
+		{"admin sem consentimento", admin, nil, http.MethodPost, "/patients/1", rbac.PermRecordWrite, http.StatusOK, ""},
+		{"aprovada e vigente", doctor, []Authorization{grant(AuthApproved, &tomorrow, false)}, http.MethodPost, "/patients/1", rbac.PermRecordWrite, http.StatusOK, ""},
+		{"aprovada sem prazo", doctor, []Authorization{grant(AuthApproved, nil, false)}, http.MethodGet, "/patients/1", rbac.PermRecordRead, http.StatusOK, ""},
+		{"sem autorização", doctor, nil, http.MethodGet, "/patients/1", rbac.PermRecordRead, http.StatusForbidden, "patient_access_denied"},
+		{"aprovada e vencida", doctor, []Authorization{grant(AuthApproved, &yesterday, false)}, http.MethodGet, "/patients/1", rbac.PermRecordRead, http.StatusForbidden, "patient_access_denied"},
+		{"revogada", doctor, []Authorization{grant(AuthRevoked, nil, false)}, http.MethodGet, "/patients/1", rbac.PermRecordRead, http.StatusForbidden, "patient_access_denied"},
+		{"pendente", doctor, []Authorization{grant(AuthPending, nil, false)}, http.MethodGet, "/patients/1", rbac.PermRecordRead, http.StatusForbidden, "patient_access_denied"},
+		{"perfil sem a permissão", nurse, []Authorization{{UserID: 3, PatientID: 1, Status: AuthApproved}}, http.MethodGet, "/patients/1", rbac.PermRecordRead, http.StatusForbidden, "patient_access_denied"},
+		{"leitura com somente leitura", doctor, []Authorization{grant(AuthEmergency, &tomorrow, true)}, http.MethodGet, "/patients/1", rbac.PermRecordRead, http.StatusOK, ""},
+		{"escrita com somente leitura", doctor, []Authorization{grant(AuthEmergency, &tomorrow, true)}, http.MethodPost, "/patients/1", rbac.PermRecordRead, http.StatusForbidden, "read_only_authorization"},
+		{"sem principal", nil, nil, http.MethodGet, "/patients/1", rbac.PermRecordRead, http.StatusUnauthorized, "unauthorized"},
+		{"id inválido", doctor, nil, http.MethodGet, "/patients/abc", rbac.PermRecordRead, http.StatusBadRequest, "invalid_patient_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(newTestGuard(tt.auths...), tt.principal, tt.method, tt.path, tt.perm)
+			if w.Code != tt.want {
+				t.Fatalf("status: got %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+			if tt.wantErr != "" && !strings.Contains(w.Body.String(), `"`+tt.wantErr+`"`) {
+				t.Errorf("body: got %s, want error %q", w.Body.String(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sonnda-api/internal/brdocs"
+)
+
+var (
+	ErrAuthorizationNotFound = errors.New("authorization not found")
+	ErrAuthorizationExists   = errors.New("authorization already pending or approved")
+	ErrInvalidTransition     = errors.New("invalid authorization status transition")
+	ErrNotAuthorizationParty = errors.New("user is not a party to this authorization")
+	ErrSelfAuthorization     = errors.New("cannot request access to own record")
+	ErrInvalidScope          = errors.New("invalid authorization scope")
+)
+
+// RequestAccess registra o pedido de acesso de um profissional ao prontuário
+// do paciente identificado pelo CPF.
+// O escopo é uma proposta; o paciente pode restringi-lo ao aprovar.
+func (s *service) RequestAccess(ctx context.Context, requesterID uint, cpf, reason string, scope AuthorizationScope) (*Authorization, error) {
+	if err := validateScope(scope); err != nil {
+		return nil, err
+	}
+	p, err := s.repo.FindByCPF(ctx, brdocs.NormalizeCPF(cpf))
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrPatientNotFound
+	}
+	if p.UserID == requesterID {
+		return nil, ErrSelfAuthorization
+	}
+
+	existing, err := s.repo.FindOpenAuthorization(ctx, requesterID, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAuthorizationExists
+	}
+
+	now := time.Now().UTC()
+	auth := &Authorization{
+		UserID:      requesterID,
+		PatientID:   p.UserID,
+		Status:      AuthPending,
+		RequestedAt: now,
+		ExpiresAt:   scope.ExpiresAt,
+		ReadOnly:    scope.ReadOnly,
+		RecordTypes: scope.RecordTypes,
+		History: []AuthorizationHistory{{
+			NewStatus: AuthPending,
+			ChangedBy: requesterID,
+			Reason:    reason,
+			ChangedAt: now,
+		}},
+	}
+	if err := s.repo.CreateAuthorization(ctx, auth); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// ListPatientAuthorizations lista as autorizações do paciente, opcionalmente
+// filtradas por status.
+func (s *service) ListPatientAuthorizations(ctx context.Context, patientID uint, status AuthStatus) ([]Authorization, error) {
+	auths, err := s.repo.FindAuthorizations(ctx, patientID)
+	if err != nil || status == "" {
+		return auths, err
+	}
+	filtered := make([]Authorization, 0, len(auths))
+	for _, a := range auths {
+		if a.Status == status {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered, nil
+}
+
+// ListRequestedAuthorizations lista os pedidos feitos pelo profissional.
+func (s *service) ListRequestedAuthorizations(ctx context.Context, requesterID uint) ([]Authorization, error) {
+	return s.repo.FindAuthorizationsByUser(ctx, requesterID)
+}
+
+// ApproveAccess e DenyAccess só podem ser feitos pelo próprio paciente. Ao
+// aprovar, scope (se informado) substitui o escopo pedido pelo profissional.
+func (s *service) ApproveAccess(ctx context.Context, patientID, authID uint, reason string, scope *AuthorizationScope) (*Authorization, error) {
+	if scope != nil {
+		if err := validateScope(*scope); err != nil {
+			return nil, err
+		}
+	}
+	auth, err := s.findPatientAuthorization(ctx, patientID, authID)
+	if err != nil {
+		return nil, err
+	}
+	if scope != nil {
+		auth.ExpiresAt = scope.ExpiresAt
+		auth.ReadOnly = scope.ReadOnly
+		auth.RecordTypes = scope.RecordTypes
+	}
+	if auth.ExpiresAt != nil && !auth.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidScope
+	}
+	return auth, s.transition(ctx, auth, AuthApproved, patientID, reason)
+}
+
+func (s *service) DenyAccess(ctx context.Context, patientID, authID uint, reason string) (*Authorization, error) {
+	auth, err := s.findPatientAuthorization(ctx, patientID, authID)
+	if err != nil {
+		return nil, err
+	}
+	return auth, s.transition(ctx, auth, AuthDenied, patientID, reason)
+}
+
+func (s *service) findPatientAuthorization(ctx context.Context, patientID, authID uint) (*Authorization, error) {
+	auth, err := s.findAuthorization(ctx, authID)
+	if err != nil {
+		return nil, err
+	}
+	if auth.PatientID != patientID {
+		return nil, ErrNotAuthorizationParty
+	}
+	return auth, nil
+}
+
+// RevokeAccess encerra a autorização; vale tanto para o paciente quanto para
+// o profissional que a solicitou.
+func (s *service) RevokeAccess(ctx context.Context, actorID, authID uint, reason string) (*Authorization, error) {
+	auth, err := s.findAuthorization(ctx, authID)
+	if err != nil {
+		return nil, err
+	}
+	if auth.PatientID != actorID && auth.UserID != actorID {
+		return nil, ErrNotAuthorizationParty
+	}
+	return auth, s.transition(ctx, auth, AuthRevoked, actorID, reason)
+}
+
+func (s *service) findAuthorization(ctx context.Context, authID uint) (*Authorization, error) {
+	auth, err := s.repo.FindAuthorizationByID(ctx, authID)
+	if err != nil {
+		return nil, err
+	}
+	if auth == nil {
+		return nil, ErrAuthorizationNotFound
+	}
+	return auth, nil
+}
+
+func validateScope(scope AuthorizationScope) error {
+	if scope.ExpiresAt != nil && !scope.ExpiresAt.After(time.Now()) {
+		return ErrInvalidScope
+	}
+	for _, t := range scope.RecordTypes {
+		if !t.Valid() {
+			return ErrInvalidScope
+		}
+	}
+	return nil
+}
+
+// transition valida a mudança de status e grava o histórico.
+func (s *service) transition(ctx context.Context, auth *Authorization, next AuthStatus, changedBy uint, reason string) error {
+	from := auth.Status
+	if !from.CanTransitionTo(next) {
+		return ErrInvalidTransition
+	}
+
+	now := time.Now().UTC()
+	auth.Status = next
+	switch next {
+	case AuthApproved:
+		auth.ApprovedAt = &now
+	case AuthDenied:
+		auth.DeniedAt = &now
+	case AuthRevoked:
+		auth.RevokedAt = &now
+	}
+
+	entry := &AuthorizationHistory{
+		OldStatus: from,
+		NewStatus: next,
+		ChangedBy: changedBy,
+		Reason:    reason,
+		ChangedAt: now,
+	}
+	if err := s.repo.TransitionAuthorization(ctx, auth, from, entry); err != nil {
+		return err
+	}
+	auth.History = append(auth.History, *entry)
+	return nil
+}
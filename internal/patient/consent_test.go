@@ -0,0 +1,64 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func (r *fakeRepo) FindByCPF(ctx context.Context, cpf string) (*PatientProfile, error) {
+	return &PatientProfile{UserID: 1, CPF: cpf}, nil
+}
+
+func (r *fakeRepo) FindOpenAuthorization(ctx context.Context, userID, patientID uint) (*Authorization, error) {
+	for i := range r.auths {
+		a := &r.auths[i]
+		if a.UserID == userID && a.PatientID == patientID && (a.Status == AuthPending || a.Status == AuthApproved) {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepo) CreateAuthorization(ctx context.Context, auth *Authorization) error {
+	r.auths = append(r.auths, *auth)
+	return nil
+}
+
+func TestRequestAccessRejectsOpenRequest(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name    string
+		auths   []Authorization
+		wantErr error
+	}{
+		{"primeiro pedido", nil, nil},
+		{"pendente", []Authorization{{UserID: 2, PatientID: 1, Status: AuthPending, RequestedAt: now}}, ErrAuthorizationExists},
+		{"aprovado", []Authorization{{UserID: 2, PatientID: 1, Status: AuthApproved, RequestedAt: now}}, ErrAuthorizationExists},
+		// a emergência mais recente não pode esconder o pedido pendente
+		{"pendente atrás de emergência", []Authorization{
+			{UserID: 2, PatientID: 1, Status: AuthEmergency, RequestedAt: now},
+			{UserID: 2, PatientID: 1, Status: AuthPending, RequestedAt: now.Add(-time.Hour)},
+		}, ErrAuthorizationExists},
+		{"negado e revogado", []Authorization{
+			{UserID: 2, PatientID: 1, Status: AuthDenied, RequestedAt: now},
+			{UserID: 2, PatientID: 1, Status: AuthRevoked, RequestedAt: now.Add(-time.Hour)},
+		}, nil},
+		{"pedido de outro profissional", []Authorization{{UserID: 3, PatientID: 1, Status: AuthPending, RequestedAt: now}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{auths: tt.auths}
+			svc := NewService(repo, nil, nil)
+			auth, err := svc.RequestAccess(context.Background(), 2, "529.982.247-25", "acompanhamento", AuthorizationScope{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (auth == nil || auth.Status != AuthPending) {
+				t.Errorf("got %+v, want a pending authorization", auth)
+			}
+		})
+	}
+}
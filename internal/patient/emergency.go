@@ -0,0 +1,92 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"sonnda-api/internal/mail"
+)
+
+// emergencyAccessTTL limita o acesso de emergência a um plantão curto.
+const emergencyAccessTTL = 4 * time.Hour
+
+var ErrActiveAccessExists = errors.New("user already has active access to this patient")
+
+// EmergencyAccess concede ao médico acesso somente leitura, sem consentimento,
+// por emergencyAccessTTL. Grava a auditoria e avisa o paciente em seguida.
+func (s *service) EmergencyAccess(ctx context.Context, doctorID, patientID uint, justification, ip, userAgent string) (*Authorization, error) {
+	if doctorID == patientID {
+		return nil, ErrSelfAuthorization
+	}
+	if _, err := s.repo.FindByUserID(ctx, patientID); err != nil {
+		return nil, err
+	}
+	active, err := s.repo.FindActiveAuthorizations(ctx, doctorID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) > 0 {
+		return nil, ErrActiveAccessExists
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(emergencyAccessTTL)
+	auth := &Authorization{
+		UserID:        doctorID,
+		PatientID:     patientID,
+		Status:        AuthEmergency,
+		RequestedAt:   now,
+		ApprovedAt:    &now,
+		ExpiresAt:     &expiresAt,
+		ReadOnly:      true,
+		Justification: justification,
+		History: []AuthorizationHistory{{
+			NewStatus: AuthEmergency,
+			ChangedBy: doctorID,
+			Reason:    justification,
+			ChangedAt: now,
+		}},
+	}
+	entry := &EmergencyAccessLog{
+		DoctorID:      doctorID,
+		PatientID:     patientID,
+		Justification: justification,
+		IP:            ip,
+		UserAgent:     userAgent,
+	}
+	if err := s.repo.CreateEmergencyAccess(ctx, auth, entry); err != nil {
+		return nil, err
+	}
+
+	// o acesso já foi concedido: falha no aviso não desfaz a emergência,
+	// fica registrada em PatientNotifiedAt nulo
+	if err := s.notifyEmergencyAccess(ctx, auth, entry); err != nil {
+		log.Printf("⚠️  Falha ao avisar paciente %d sobre acesso de emergência: %v", patientID, err)
+	}
+	return auth, nil
+}
+
+func (s *service) notifyEmergencyAccess(ctx context.Context, auth *Authorization, entry *EmergencyAccessLog) error {
+	email, err := s.repo.FindUserEmail(ctx, auth.PatientID)
+	if err != nil {
+		return err
+	}
+	msg := mail.Message{
+		To:      email,
+		Subject: "Sonnda - Acesso de emergência ao seu prontuário",
+		Body: fmt.Sprintf("Um profissional acessou seu prontuário em caráter de emergência em %s.\n\n"+
+			"Justificativa informada: %s\n\n"+
+			"O acesso é somente leitura e expira em %s. Você pode revogá-lo a qualquer "+
+			"momento em suas autorizações.",
+			auth.RequestedAt.Format("02/01/2006 15:04 MST"),
+			auth.Justification,
+			auth.ExpiresAt.Format("02/01/2006 15:04 MST")),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return err
+	}
+	return s.repo.MarkEmergencyNotified(ctx, entry.ID, time.Now().UTC())
+}
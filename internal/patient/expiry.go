@@ -0,0 +1,62 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// systemUserID marca no histórico as mudanças feitas pelo próprio sistema.
+const systemUserID uint = 0
+
+const expiryBatchSize = 100
+
+// ExpireAuthorizations marca como EXPIRED as autorizações vencidas e retorna
+// quantas foram alteradas.
+func (s *service) ExpireAuthorizations(ctx context.Context, now time.Time) (int, error) {
+	expired := 0
+	for {
+		auths, err := s.repo.FindExpiredAuthorizations(ctx, now, expiryBatchSize)
+		if err != nil {
+			return expired, err
+		}
+		changed := 0
+		for i := range auths {
+			err := s.transition(ctx, &auths[i], AuthExpired, systemUserID, "prazo da autorização encerrado")
+			if errors.Is(err, ErrAuthorizationConflict) {
+				// revogada/alterada por outra requisição no meio do caminho
+				continue
+			}
+			if err != nil {
+				return expired, err
+			}
+			changed++
+		}
+		expired += changed
+		if len(auths) < expiryBatchSize || changed == 0 {
+			return expired, nil
+		}
+	}
+}
+
+// WatchExpirations roda ExpireAuthorizations a cada interval até ctx acabar.
+func WatchExpirations(ctx context.Context, svc Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := svc.ExpireAuthorizations(ctx, time.Now().UTC())
+			if err != nil {
+				log.Printf("⚠️  Falha ao expirar autorizações: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("⏰ %d autorização(ões) expirada(s)", n)
+			}
+		}
+	}
+}
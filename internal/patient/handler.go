@@ -1,42 +1,590 @@
 package patient
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sonnda-api/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Handler encapsula as rotas de usuário (registro e login).
+// Handler encapsula as rotas de pacientes (cadastro, perfil e consentimento).
 type Handler struct {
 	svc Service
 }
 
-// NewHandler cria um novo User handler com o Service injetado.
+// NewHandler cria um novo patient handler com o Service injetado.
 func NewHandler(svc Service) *Handler {
 	return &Handler{svc: svc}
 }
 
 type registerRequest struct {
-	FullName string  `json:"full_name" binding:"required,min=2"`
-	CPF      string  `json:"cpf" binding:"required,len=11"`
-	CNS      string  `json:"cns" binding:"required,len=15"`
-	Email    *string `json:"email" binding:"omitempty,email"`
-	Phone    *string `json:"phone" binding:"omitempty"`
-	Password string  `json:"password" binding:"required,min=6"`
+	FullName  string  `json:"full_name" binding:"required,min=2"`
+	CPF       string  `json:"cpf" binding:"required,cpf"`
+	CNS       string  `json:"cns" binding:"required,cns"`
+	Email     string  `json:"email" binding:"required,email"`
+	Phone     *string `json:"phone" binding:"omitempty"`
+	Password  string  `json:"password" binding:"required,min=6"`
+	BirthDate string  `json:"birth_date" binding:"omitempty,datetime=2006-01-02"`
+	Gender    Gender  `json:"gender" binding:"omitempty,oneof=MALE FEMALE OTHER UNKNOWN"`
+	Race      Race    `json:"race" binding:"omitempty,oneof=WHITE BLACK ASIAN MIXED INDIGENOUS UNKNOWN"`
+}
+
+type updateProfileRequest struct {
+	FullName  *string `json:"full_name" binding:"omitempty,min=2"`
+	CNS       *string `json:"cns" binding:"omitempty,cns"`
+	Phone     *string `json:"phone" binding:"omitempty"`
+	AvatarURL *string `json:"avatar_url" binding:"omitempty,url"`
+	BirthDate *string `json:"birth_date" binding:"omitempty,datetime=2006-01-02"`
+	Gender    *Gender `json:"gender" binding:"omitempty,oneof=MALE FEMALE OTHER UNKNOWN"`
+	Race      *Race   `json:"race" binding:"omitempty,oneof=WHITE BLACK ASIAN MIXED INDIGENOUS UNKNOWN"`
 }
 
-// Register trata POST /users/register
+// Register trata POST /patients/register
 func (h *Handler) Register(c *gin.Context) {
 	var req registerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
 		return
 	}
 
-}
+	in := RegisterInput{
+		Email:    req.Email,
+		Password: req.Password,
+		FullName: req.FullName,
+		CPF:      req.CPF,
+		CNS:      &req.CNS,
+		Gender:   req.Gender,
+		Race:     req.Race,
+		Phone:    req.Phone,
+	}
+	if req.BirthDate != "" {
+		// formato já validado pelo binding
+		in.BirthDate, _ = time.Parse(time.DateOnly, req.BirthDate)
+	}
 
-// Login trata POST /users/login
+	p, err := h.svc.Register(c, in)
+	if err != nil {
+		writeProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"email":   req.Email,
+		"patient": p,
+	})
+}
 
+// Me trata GET /patients/me
 func (h *Handler) Me(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	p, err := h.svc.GetOwnProfile(c, userID)
+	if err != nil {
+		writeProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// UpdateMe trata PUT /patients/me
+func (h *Handler) UpdateMe(c *gin.Context) {
+	var req updateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+
+	in := UpdateProfileInput{
+		FullName:  req.FullName,
+		CNS:       req.CNS,
+		Gender:    req.Gender,
+		Race:      req.Race,
+		Phone:     req.Phone,
+		AvatarURL: req.AvatarURL,
+	}
+	if req.BirthDate != nil {
+		birthDate, _ := time.Parse(time.DateOnly, *req.BirthDate)
+		in.BirthDate = &birthDate
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	p, err := h.svc.UpdateProfile(c, userID, in)
+	if err != nil {
+		writeProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+func writeProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrPatientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "patient_not_found"})
+	case errors.Is(err, ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "email_taken"})
+	case errors.Is(err, ErrCPFTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "cpf_taken"})
+	case errors.Is(err, ErrCNSTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "cns_taken"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+	}
+}
+
+// GetPatient trata GET /patients/:id
+func (h *Handler) GetPatient(c *gin.Context) {
+	patientID, _ := PatientIDFromContext(c)
+
+	p, err := h.svc.GetProfile(c, patientID)
+	if err != nil {
+		if errors.Is(err, ErrPatientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "patient_not_found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// scopeRequest descreve o escopo no corpo das requisições de consentimento.
+type scopeRequest struct {
+	ExpiresInDays *int                `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+	ReadOnly      bool                `json:"read_only"`
+	RecordTypes   []MedicalRecordType `json:"record_types"`
+}
+
+func (r scopeRequest) isZero() bool {
+	return r.ExpiresInDays == nil && !r.ReadOnly && len(r.RecordTypes) == 0
+}
+
+func (r scopeRequest) toScope() AuthorizationScope {
+	scope := AuthorizationScope{ReadOnly: r.ReadOnly, RecordTypes: r.RecordTypes}
+	if r.ExpiresInDays != nil {
+		expiresAt := time.Now().UTC().AddDate(0, 0, *r.ExpiresInDays)
+		scope.ExpiresAt = &expiresAt
+	}
+	return scope
+}
+
+type accessRequest struct {
+	CPF    string `json:"cpf" binding:"required,cpf"`
+	Reason string `json:"reason" binding:"omitempty,max=500"`
+	scopeRequest
+}
+
+type approveRequest struct {
+	Reason string `json:"reason" binding:"omitempty,max=500"`
+	scopeRequest
+}
+
+type decisionRequest struct {
+	Reason string `json:"reason" binding:"omitempty,max=500"`
+}
+
+// RequestAccess trata POST /authorizations
+func (h *Handler) RequestAccess(c *gin.Context) {
+	var req accessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	auth, err := h.svc.RequestAccess(c, userID, req.CPF, req.Reason, req.toScope())
+	if err != nil {
+		writeConsentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, auth)
+}
+
+// ListRequested trata GET /authorizations/requested
+func (h *Handler) ListRequested(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	auths, err := h.svc.ListRequestedAuthorizations(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, auths)
+}
+
+// ListMyAuthorizations trata GET /patients/me/authorizations?status=PENDING
+func (h *Handler) ListMyAuthorizations(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	status := AuthStatus(strings.ToUpper(c.Query("status")))
+
+	auths, err := h.svc.ListPatientAuthorizations(c, userID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, auths)
+}
+
+// ApproveAccess trata POST /patients/me/authorizations/:authId/approve
+// Sem escopo no corpo, vale o escopo pedido pelo profissional.
+func (h *Handler) ApproveAccess(c *gin.Context) {
+	authID, err := strconv.ParseUint(c.Param("authId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id"})
+		return
+	}
+	var req approveRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+
+	var scope *AuthorizationScope
+	if !req.isZero() {
+		s := req.toScope()
+		scope = &s
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	auth, err := h.svc.ApproveAccess(c, userID, uint(authID), req.Reason, scope)
+	if err != nil {
+		writeConsentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, auth)
+}
+
+// DenyAccess trata POST /patients/me/authorizations/:authId/deny
+func (h *Handler) DenyAccess(c *gin.Context) {
+	h.decide(c, h.svc.DenyAccess)
+}
+
+// RevokeAccess trata POST /authorizations/:authId/revoke
+func (h *Handler) RevokeAccess(c *gin.Context) {
+	h.decide(c, h.svc.RevokeAccess)
+}
+
+func (h *Handler) decide(c *gin.Context, action func(context.Context, uint, uint, string) (*Authorization, error)) {
+	authID, err := strconv.ParseUint(c.Param("authId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id"})
+		return
+	}
+	var req decisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	auth, err := action(c, userID, uint(authID), req.Reason)
+	if err != nil {
+		writeConsentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, auth)
+}
+
+type recordRequest struct {
+	EntryType    MedicalRecordType `json:"entry_type" binding:"required"`
+	Title        string            `json:"title" binding:"required,max=255"`
+	Description  string            `json:"description"`
+	Date         time.Time         `json:"date" binding:"required"`
+	Prevention   *Prevention       `json:"prevention"`
+	Problem      *Problem          `json:"problem"`
+	Exam         *Exam             `json:"exam"`
+	PhysicalExam *PhysicalExam     `json:"physical_exam"`
+}
+
+func (r recordRequest) toRecord() *MedicalRecord {
+	return &MedicalRecord{
+		EntryType:        r.EntryType,
+		Title:            r.Title,
+		Description:      r.Description,
+		Date:             r.Date,
+		PreventionData:   r.Prevention,
+		ProblemData:      r.Problem,
+		ExamData:         r.Exam,
+		PhysicalExamData: r.PhysicalExam,
+	}
+}
+
+// CreateRecord trata POST /patients/:id/records
+func (h *Handler) CreateRecord(c *gin.Context) {
+	var req recordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+	if !ScopeCovers(c, req.EntryType) {
+		writeRecordError(c, ErrRecordOutOfScope)
+		return
+	}
+
+	patientID, _ := PatientIDFromContext(c)
+	userID, _ := middleware.GetUserID(c)
+	record, err := h.svc.CreateRecord(c, userID, patientID, req.toRecord())
+	if err != nil {
+		writeRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, record)
+}
+
+// ListRecords trata GET /patients/:id/records?type=EXAM,NOTE&from=2024-01-01&to=2024-12-31&limit=50&offset=0
+// from/to são datas inclusivas.
+func (h *Handler) ListRecords(c *gin.Context) {
+	var filter RecordFilter
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	filter.Offset, _ = strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	if raw := c.Query("type"); raw != "" {
+		for _, t := range strings.Split(raw, ",") {
+			filter.Types = append(filter.Types, MedicalRecordType(strings.ToUpper(strings.TrimSpace(t))))
+		}
+	}
+	var ok bool
+	if filter.From, filter.To, ok = parseDateRange(c); !ok {
+		return
+	}
+
+	// consentimento restrito a alguns tipos: a linha do tempo só mostra esses
+	if auth := AuthorizationFromContext(c); auth != nil && len(auth.RecordTypes) > 0 {
+		if len(filter.Types) == 0 {
+			filter.Types = auth.RecordTypes
+		}
+		for _, t := range filter.Types {
+			if !auth.CoversRecordType(t) {
+				writeRecordError(c, ErrRecordOutOfScope)
+				return
+			}
+		}
+	}
+
+	patientID, _ := PatientIDFromContext(c)
+	records, total, err := h.svc.ListRecords(c, patientID, filter)
+	if err != nil {
+		writeRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items":  records,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
 
+// GetRecord trata GET /patients/:id/records/:recordId
+func (h *Handler) GetRecord(c *gin.Context) {
+	recordID, err := strconv.ParseUint(c.Param("recordId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id"})
+		return
+	}
+
+	patientID, _ := PatientIDFromContext(c)
+	record, err := h.svc.GetRecord(c, patientID, uint(recordID))
+	if err != nil {
+		writeRecordError(c, err)
+		return
+	}
+	if !ScopeCovers(c, record.EntryType) {
+		// fora do escopo é tratado como inexistente
+		writeRecordError(c, ErrRecordNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// UpdateRecord trata PUT /patients/:id/records/:recordId
+func (h *Handler) UpdateRecord(c *gin.Context) {
+	recordID, err := strconv.ParseUint(c.Param("recordId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_id"})
+		return
+	}
+	var req recordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+	if !ScopeCovers(c, req.EntryType) {
+		writeRecordError(c, ErrRecordOutOfScope)
+		return
+	}
+
+	patientID, _ := PatientIDFromContext(c)
+	record, err := h.svc.UpdateRecord(c, patientID, uint(recordID), req.toRecord())
+	if err != nil {
+		writeRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// VitalsTrend trata GET /patients/:id/vitals?from=2024-01-01&to=2024-12-31
+func (h *Handler) VitalsTrend(c *gin.Context) {
+	if !ScopeCovers(c, RecordTypePhysicalExam) {
+		writeRecordError(c, ErrRecordOutOfScope)
+		return
+	}
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	patientID, _ := PatientIDFromContext(c)
+	points, err := h.svc.VitalsTrend(c, patientID, from, to)
+	if err != nil {
+		writeRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, points)
+}
+
+// ProblemList trata GET /patients/:id/problems?include=all
+func (h *Handler) ProblemList(c *gin.Context) {
+	if !ScopeCovers(c, RecordTypeProblem) {
+		writeRecordError(c, ErrRecordOutOfScope)
+		return
+	}
+
+	patientID, _ := PatientIDFromContext(c)
+	list, err := h.svc.ProblemList(c, patientID, c.Query("include") == "all")
+	if err != nil {
+		writeRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// PreventionSchedule trata GET /patients/:id/prevention/schedule
+func (h *Handler) PreventionSchedule(c *gin.Context) {
+	if !ScopeCovers(c, RecordTypePrevention) {
+		writeRecordError(c, ErrRecordOutOfScope)
+		return
+	}
+
+	patientID, _ := PatientIDFromContext(c)
+	schedule, err := h.svc.PreventionSchedule(c, patientID)
+	if err != nil {
+		if errors.Is(err, ErrBirthDateRequired) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "birth_date_required"})
+			return
+		}
+		writeRecordError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// parseDateRange lê from/to (datas inclusivas) da query. Devolve To já como
+// limite exclusivo. Em caso de erro, responde 400 e retorna ok=false.
+func parseDateRange(c *gin.Context) (from, to *time.Time, ok bool) {
+	if raw := c.Query("from"); raw != "" {
+		t, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_from"})
+			return nil, nil, false
+		}
+		from = &t
+	}
+	if raw := c.Query("to"); raw != "" {
+		t, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_to"})
+			return nil, nil, false
+		}
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+	return from, to, true
+}
+
+func writeRecordError(c *gin.Context, err error) {
+	var rangeErr *VitalRangeError
+	if errors.As(err, &rangeErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "implausible_vital",
+			"field": rangeErr.Field,
+			"value": rangeErr.Value,
+			"min":   rangeErr.Min,
+			"max":   rangeErr.Max,
+			"unit":  rangeErr.Unit,
+		})
+		return
+	}
+	switch {
+	case errors.Is(err, ErrPatientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "patient_not_found"})
+	case errors.Is(err, ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "record_not_found"})
+	case errors.Is(err, ErrInvalidRecordType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_entry_type"})
+	case errors.Is(err, ErrRecordPayloadMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payload_mismatch"})
+	case errors.Is(err, ErrInvalidRecordPayload):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_payload"})
+	case errors.Is(err, ErrInvalidCIDCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_cid_code"})
+	case errors.Is(err, ErrRecordOutOfScope):
+		c.JSON(http.StatusForbidden, gin.H{"error": "out_of_authorization_scope"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+	}
+}
+
+type emergencyAccessRequest struct {
+	Justification string `json:"justification" binding:"required,min=20,max=2000"`
+}
+
+// EmergencyAccess trata POST /patients/:id/emergency-access
+func (h *Handler) EmergencyAccess(c *gin.Context) {
+	patientID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_patient_id"})
+		return
+	}
+	var req emergencyAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	auth, err := h.svc.EmergencyAccess(c, userID, uint(patientID), req.Justification, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, ErrActiveAccessExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "active_access_exists"})
+			return
+		}
+		writeConsentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, auth)
+}
+
+func writeConsentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrPatientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "patient_not_found"})
+	case errors.Is(err, ErrAuthorizationNotFound), errors.Is(err, ErrNotAuthorizationParty):
+		// não revela autorizações de terceiros
+		c.JSON(http.StatusNotFound, gin.H{"error": "authorization_not_found"})
+	case errors.Is(err, ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope"})
+	case errors.Is(err, ErrSelfAuthorization):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "self_authorization"})
+	case errors.Is(err, ErrAuthorizationExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "authorization_exists"})
+	case errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrAuthorizationConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "invalid_status_transition"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+	}
 }
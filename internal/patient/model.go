@@ -2,12 +2,16 @@ package patient
 
 import (
 	"time"
+
+	"sonnda-api/internal/brdocs"
+
+	"gorm.io/gorm"
 )
 
 type PatientProfile struct {
 	UserID    uint       `gorm:"primaryKey" json:"user_id"`
 	CPF       string     `gorm:"size:11;not null;uniqueIndex" json:"cpf"`
-	CNS       *string    `gorm:"size:15" json:"cns,omitempty"`
+	CNS       *string    `gorm:"size:15;uniqueIndex" json:"cns,omitempty"`
 	FullName  string     `gorm:"size:255;not null" json:"full_name"`
 	BirthDate time.Time  `json:"birth_date"`
 	Gender    Gender     `gorm:"type:varchar(20)" json:"gender"`
@@ -18,8 +22,18 @@ type PatientProfile struct {
 	UpdatedAt *time.Time `gorm:"autoUpdateTime" json:"updated_at,omitempty"`
 
 	// Relacionamentos
-	MedicalRecords []MedicalRecord `gorm:"foreignKey:UserID" json:"medical_records"`
-	Authorizations []Authorization `gorm:"foreignKey:PatientID" json:"authorizations"`
+	MedicalRecords []MedicalRecord `gorm:"foreignKey:UserID" json:"medical_records,omitempty"`
+	Authorizations []Authorization `gorm:"foreignKey:PatientID" json:"authorizations,omitempty"`
+}
+
+// BeforeSave garante CPF/CNS só com dígitos, como nas buscas.
+func (p *PatientProfile) BeforeSave(tx *gorm.DB) error {
+	p.CPF = brdocs.NormalizeCPF(p.CPF)
+	if p.CNS != nil {
+		cns := brdocs.NormalizeCNS(*p.CNS)
+		p.CNS = &cns
+	}
+	return nil
 }
 
 type Gender string
@@ -70,6 +84,14 @@ const (
 	RecordTypeNote         MedicalRecordType = "NOTE"
 )
 
+func (t MedicalRecordType) Valid() bool {
+	switch t {
+	case RecordTypePrevention, RecordTypeProblem, RecordTypeExam, RecordTypePhysicalExam, RecordTypeNote:
+		return true
+	}
+	return false
+}
+
 // Estruturas do seu Kotlin adaptadas
 type Prevention struct {
 	ID              uint   `gorm:"primaryKey" json:"id"`
@@ -85,15 +107,28 @@ type Prevention struct {
 }
 
 type Problem struct {
-	ID              uint   `gorm:"primaryKey" json:"id"`
-	MedicalRecordID uint   `json:"medical_record_id"`
-	Name            string `gorm:"not null" json:"name"`
-	Abbreviation    string `json:"abbreviation,omitempty"`
-	BodySystem      string `json:"body_system,omitempty"`
-	Description     string `json:"description,omitempty"`
-	Other           string `json:"other,omitempty"`
+	ID              uint          `gorm:"primaryKey" json:"id"`
+	MedicalRecordID uint          `json:"medical_record_id"`
+	Name            string        `gorm:"not null" json:"name"`
+	Abbreviation    string        `json:"abbreviation,omitempty"`
+	BodySystem      string        `json:"body_system,omitempty"`
+	Description     string        `json:"description,omitempty"`
+	Other           string        `json:"other,omitempty"`
+	CIDCode         string        `gorm:"column:cid_code;size:5;index" json:"cid_code,omitempty"`                       // ex.: "E11.9"
+	CIDUnverified   bool          `gorm:"column:cid_unverified;not null;default:false" json:"cid_unverified,omitempty"` // código fora da tabela CID-10 carregada
+	Status          ProblemStatus `gorm:"type:varchar(20);not null;default:ACTIVE" json:"status"`
+	OnsetDate       *time.Time    `json:"onset_date,omitempty"`
+	ResolvedAt      *time.Time    `json:"resolved_at,omitempty"`
 }
 
+type ProblemStatus string
+
+const (
+	ProblemActive   ProblemStatus = "ACTIVE"
+	ProblemResolved ProblemStatus = "RESOLVED"
+	ProblemInactive ProblemStatus = "INACTIVE"
+)
+
 type Exam struct {
 	ID              uint      `gorm:"primaryKey" json:"id"`
 	MedicalRecordID uint      `json:"medical_record_id"`
@@ -106,11 +141,28 @@ type Exam struct {
 	Date            time.Time `json:"date,omitempty"`
 }
 
+// PhysicalExam guarda os sinais vitais em unidades canônicas (indicadas no
+// nome do campo). A API não converte unidades: °F, lb ou metros precisam ser
+// convertidos pelo cliente. Campos nulos não foram aferidos.
 type PhysicalExam struct {
-	ID              uint   `gorm:"primaryKey" json:"id"`
-	MedicalRecordID uint   `json:"medical_record_id"`
-	SystolicBP      string `json:"systolic_bp"`
-	DiastolicBP     string `json:"diastolic_bp"`
+	ID              uint     `gorm:"primaryKey" json:"id"`
+	MedicalRecordID uint     `json:"medical_record_id"`
+	SystolicBP      *int     `json:"systolic_bp,omitempty"`             // mmHg
+	DiastolicBP     *int     `json:"diastolic_bp,omitempty"`            // mmHg
+	HeartRate       *int     `json:"heart_rate,omitempty"`              // bpm
+	RespiratoryRate *int     `json:"respiratory_rate,omitempty"`        // irpm
+	TemperatureC    *float64 `json:"temperature_c,omitempty"`           // °C
+	SpO2            *int     `gorm:"column:spo2" json:"spo2,omitempty"` // %
+	WeightKg        *float64 `json:"weight_kg,omitempty"`               // kg
+	HeightCm        *float64 `json:"height_cm,omitempty"`               // cm
+	BMI             *float64 `json:"bmi,omitempty"`                     // calculado a partir de peso e altura
+	WaistCm         *float64 `json:"waist_cm,omitempty"`                // cm
+}
+
+// BeforeSave recalcula o IMC para que nunca divirja de peso e altura.
+func (p *PhysicalExam) BeforeSave(tx *gorm.DB) error {
+	p.BMI = computeBMI(p.WeightKg, p.HeightCm)
+	return nil
 }
 
 // Sistema de autorizações com histórico
@@ -121,27 +173,104 @@ type Authorization struct {
 	Status      AuthStatus `gorm:"type:varchar(20);not null" json:"status"`
 	RequestedAt time.Time  `json:"requested_at"`
 	ApprovedAt  *time.Time `json:"approved_at,omitempty"`
+	DeniedAt    *time.Time `json:"denied_at,omitempty"`
 	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at,omitempty"`
+
+	// Escopo: vazio = todos os tipos de registro; ReadOnly bloqueia escrita
+	ReadOnly bool `gorm:"not null;default:false" json:"read_only"`
+	// Justificativa obrigatória nos acessos de emergência
+	Justification string              `json:"justification,omitempty"`
+	RecordTypes   []MedicalRecordType `gorm:"serializer:json" json:"record_types,omitempty"`
 
 	// Histórico de alterações
 	History []AuthorizationHistory `gorm:"foreignKey:AuthorizationID" json:"history"`
 }
 
+// IsActive informa se a autorização concede acesso no instante informado.
+func (a *Authorization) IsActive(now time.Time) bool {
+	if (a.Status != AuthApproved && a.Status != AuthEmergency) || a.RevokedAt != nil {
+		return false
+	}
+	return a.ExpiresAt == nil || now.Before(*a.ExpiresAt)
+}
+
+// CoversRecordType informa se o escopo inclui o tipo de registro.
+func (a *Authorization) CoversRecordType(t MedicalRecordType) bool {
+	if len(a.RecordTypes) == 0 {
+		return true
+	}
+	for _, allowed := range a.RecordTypes {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizationScope limita uma autorização no tempo e no conteúdo.
+type AuthorizationScope struct {
+	ExpiresAt   *time.Time
+	ReadOnly    bool
+	RecordTypes []MedicalRecordType
+}
+
 type AuthStatus string
 
 const (
 	AuthPending  AuthStatus = "PENDING"
 	AuthApproved AuthStatus = "APPROVED"
+	AuthDenied   AuthStatus = "DENIED"
 	AuthRevoked  AuthStatus = "REVOKED"
 	AuthExpired  AuthStatus = "EXPIRED"
+	// AuthEmergency é o acesso de emergência ("break-glass"), concedido sem
+	// consentimento prévio e sempre auditado.
+	AuthEmergency AuthStatus = "EMERGENCY"
 )
 
+// authTransitions lista as mudanças de status permitidas. DENIED, REVOKED e
+// EXPIRED são finais: um novo acesso exige uma nova solicitação.
+var authTransitions = map[AuthStatus][]AuthStatus{
+	AuthPending:   {AuthApproved, AuthDenied, AuthRevoked, AuthExpired},
+	AuthApproved:  {AuthRevoked, AuthExpired},
+	AuthEmergency: {AuthRevoked, AuthExpired},
+}
+
+// grantsAccess lista os status que liberam acesso enquanto vigentes.
+var grantsAccess = []AuthStatus{AuthApproved, AuthEmergency}
+
+// openRequest lista os status que impedem um novo pedido do mesmo usuário.
+var openRequest = []AuthStatus{AuthPending, AuthApproved}
+
+// CanTransitionTo informa se a mudança de status é permitida.
+func (s AuthStatus) CanTransitionTo(next AuthStatus) bool {
+	for _, allowed := range authTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type AuthorizationHistory struct {
-	ID              uint       `gorm:"primaryKey"`
-	AuthorizationID uint       `gorm:"not null"`
-	OldStatus       AuthStatus `gorm:"type:varchar(20)"`
-	NewStatus       AuthStatus `gorm:"type:varchar(20);not null"`
-	ChangedBy       uint       `gorm:"not null"` // UserID de quem mudou
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	AuthorizationID uint       `gorm:"not null" json:"authorization_id"`
+	OldStatus       AuthStatus `gorm:"type:varchar(20)" json:"old_status,omitempty"`
+	NewStatus       AuthStatus `gorm:"type:varchar(20);not null" json:"new_status"`
+	ChangedBy       uint       `gorm:"not null" json:"changed_by"` // UserID de quem mudou
 	Reason          string     `json:"reason,omitempty"`
 	ChangedAt       time.Time  `json:"changed_at"`
 }
+
+// EmergencyAccessLog é a trilha de auditoria dos acessos de emergência.
+type EmergencyAccessLog struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	AuthorizationID   uint       `gorm:"not null;index" json:"authorization_id"`
+	DoctorID          uint       `gorm:"not null;index" json:"doctor_id"`
+	PatientID         uint       `gorm:"not null;index" json:"patient_id"`
+	Justification     string     `gorm:"type:text;not null" json:"justification"`
+	IP                string     `gorm:"size:64" json:"ip"`
+	UserAgent         string     `json:"user_agent"`
+	CreatedAt         time.Time  `json:"created_at"`
+	PatientNotifiedAt *time.Time `json:"patient_notified_at,omitempty"`
+}
@@ -0,0 +1,20 @@
+package patient
+
+import "testing"
+
+func TestAuthTransitions(t *testing.T) {
+	all := []AuthStatus{AuthPending, AuthApproved, AuthDenied, AuthRevoked, AuthExpired, AuthEmergency}
+	allowed := map[AuthStatus]map[AuthStatus]bool{
+		AuthPending:   {AuthApproved: true, AuthDenied: true, AuthRevoked: true, AuthExpired: true},
+		AuthApproved:  {AuthRevoked: true, AuthExpired: true},
+		AuthEmergency: {AuthRevoked: true, AuthExpired: true},
+		// DENIED, REVOKED e EXPIRED são finais
+	}
+	for _, from := range all {
+		for _, to := range all {
+			if got, want := from.CanTransitionTo(to), allowed[from][to]; got != want {
+				t.Errorf("%s -> %s: got %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
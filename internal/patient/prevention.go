@@ -0,0 +1,58 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sonnda-api/internal/prevention"
+)
+
+var ErrBirthDateRequired = errors.New("patient birth date is required for the prevention schedule")
+
+// PreventionSchedule é o calendário preventivo calculado para o paciente.
+type PreventionSchedule struct {
+	RulesVersion string            `json:"rules_version"`
+	Age          int               `json:"age"`
+	Items        []prevention.Item `json:"items"`
+}
+
+// PreventionSchedule cruza idade, sexo e registros PREVENTION com as regras.
+func (s *service) PreventionSchedule(ctx context.Context, patientID uint) (*PreventionSchedule, error) {
+	rules, err := prevention.Default()
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.repo.FindByUserID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if p.BirthDate.IsZero() {
+		return nil, ErrBirthDateRequired
+	}
+
+	records, _, err := s.repo.FindMedicalRecords(ctx, patientID, RecordFilter{
+		Types: []MedicalRecordType{RecordTypePrevention},
+		Limit: -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]prevention.Event, 0, len(records)*2)
+	for _, r := range records {
+		if r.PreventionData == nil {
+			continue
+		}
+		events = append(events, prevention.Event{Name: r.PreventionData.Name, Date: r.Date})
+		if r.PreventionData.Abbreviation != "" {
+			events = append(events, prevention.Event{Name: r.PreventionData.Abbreviation, Date: r.Date})
+		}
+	}
+
+	now := time.Now()
+	return &PreventionSchedule{
+		RulesVersion: rules.Version,
+		Age:          prevention.AgeAt(p.BirthDate, now),
+		Items:        rules.Schedule(p.BirthDate, string(p.Gender), events, now),
+	}, nil
+}
@@ -0,0 +1,129 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"sonnda-api/internal/cid10"
+)
+
+var ErrInvalidCIDCode = errors.New("invalid CID-10 code")
+
+// normalize codifica o problema na CID-10 e valida o status. Sem nome,
+// usa a descrição da tabela. Código bem formado fora da tabela é aceito e
+// marcado como não verificado; nesse caso o nome é obrigatório.
+func (p *Problem) normalize(recordDate time.Time) error {
+	if p.CIDCode != "" {
+		code, err := cid10.Normalize(p.CIDCode)
+		if err != nil {
+			return ErrInvalidCIDCode
+		}
+		catalog, err := cid10.Default()
+		if err != nil {
+			return err
+		}
+		p.CIDCode = code
+		entry, ok := catalog.Lookup(code)
+		p.CIDUnverified = !ok
+		if ok && strings.TrimSpace(p.Name) == "" {
+			p.Name = entry.Description
+		}
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		return ErrInvalidRecordPayload
+	}
+
+	if p.Status == "" {
+		p.Status = ProblemActive
+	}
+	switch p.Status {
+	case ProblemActive, ProblemInactive:
+		if p.ResolvedAt != nil {
+			return ErrInvalidRecordPayload
+		}
+	case ProblemResolved:
+		if p.ResolvedAt == nil {
+			resolved := recordDate
+			p.ResolvedAt = &resolved
+		}
+	default:
+		return ErrInvalidRecordPayload
+	}
+	if p.OnsetDate != nil && p.ResolvedAt != nil && p.ResolvedAt.Before(*p.OnsetDate) {
+		return ErrInvalidRecordPayload
+	}
+	return nil
+}
+
+// ProblemListEntry é o estado atual de um problema na lista do paciente.
+type ProblemListEntry struct {
+	CIDCode       string        `json:"cid_code,omitempty"`
+	CIDUnverified bool          `json:"cid_unverified,omitempty"`
+	Name          string        `json:"name"`
+	Status        ProblemStatus `json:"status"`
+	OnsetDate     *time.Time    `json:"onset_date,omitempty"`
+	ResolvedAt    *time.Time    `json:"resolved_at,omitempty"`
+	RecordID      uint          `json:"record_id"`
+	UpdatedAt     time.Time     `json:"updated_at"` // data do registro mais recente
+}
+
+// ProblemList reconcilia os registros PROBLEM: para cada código CID (ou nome,
+// se não codificado) vale o registro mais recente. Sem includeAll, só os
+// problemas ativos.
+func (s *service) ProblemList(ctx context.Context, patientID uint, includeAll bool) ([]ProblemListEntry, error) {
+	records, err := s.repo.FindProblems(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// records vem do mais antigo para o mais recente: o último vence
+	latest := make(map[string]ProblemListEntry)
+	for _, r := range records {
+		p := r.ProblemData
+		if p == nil {
+			continue
+		}
+		key := p.CIDCode
+		if key == "" {
+			key = "name:" + strings.ToLower(strings.TrimSpace(p.Name))
+		}
+		latest[key] = ProblemListEntry{
+			CIDCode:       p.CIDCode,
+			CIDUnverified: p.CIDUnverified,
+			Name:          p.Name,
+			Status:        p.Status,
+			OnsetDate:     p.OnsetDate,
+			ResolvedAt:    p.ResolvedAt,
+			RecordID:      r.ID,
+			UpdatedAt:     r.Date,
+		}
+	}
+
+	list := make([]ProblemListEntry, 0, len(latest))
+	for _, entry := range latest {
+		if includeAll || entry.Status == ProblemActive {
+			list = append(list, entry)
+		}
+	}
+	// ativos primeiro, depois inativos e resolvidos; em cada grupo, os
+	// atualizados mais recentemente
+	sort.Slice(list, func(i, j int) bool {
+		if ri, rj := problemRank[list[i].Status], problemRank[list[j].Status]; ri != rj {
+			return ri < rj
+		}
+		if !list[i].UpdatedAt.Equal(list[j].UpdatedAt) {
+			return list[i].UpdatedAt.After(list[j].UpdatedAt)
+		}
+		return list[i].RecordID > list[j].RecordID
+	})
+	return list, nil
+}
+
+var problemRank = map[ProblemStatus]int{
+	ProblemActive:   0,
+	ProblemInactive: 1,
+	ProblemResolved: 2,
+}
@@ -0,0 +1,58 @@
+package patient
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func (r *fakeRepo) FindProblems(ctx context.Context, patientID uint) ([]MedicalRecord, error) {
+	return r.problems, nil
+}
+
+func TestProblemList(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	problem := func(id uint, date time.Time, cid, name string, status ProblemStatus) MedicalRecord {
+		return MedicalRecord{ID: id, EntryType: RecordTypeProblem, Date: date,
+			ProblemData: &Problem{CIDCode: cid, Name: name, Status: status}}
+	}
+	// do mais antigo para o mais recente, como FindProblems
+	repo := &fakeRepo{problems: []MedicalRecord{
+		problem(1, day(1), "I10", "Hipertensão", ProblemActive),
+		problem(2, day(2), "J45.9", "Asma", ProblemActive),
+		problem(3, day(3), "", "Lombalgia", ProblemResolved),
+		problem(4, day(4), "E11.9", "Diabetes", ProblemActive),
+		problem(5, day(5), "J45.9", "Asma", ProblemInactive),  // substitui o 2
+		problem(6, day(6), "", "  lombalgia ", ProblemActive), // mesmo nome do 3
+		problem(7, day(7), "K29.7", "Gastrite", ProblemResolved),
+		problem(8, day(8), "F41.1", "Ansiedade", ProblemInactive),
+		{ID: 9, EntryType: RecordTypeProblem, Date: day(9)}, // sem dados de problema
+	}}
+	svc := NewService(repo, nil, nil)
+
+	ids := func(list []ProblemListEntry) []uint {
+		out := []uint{}
+		for _, e := range list {
+			out = append(out, e.RecordID)
+		}
+		return out
+	}
+
+	all, err := svc.ProblemList(context.Background(), 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ativos, inativos e resolvidos; em cada grupo, o mais recente primeiro
+	if got, want := ids(all), []uint{6, 4, 1, 8, 5, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("all: got %v, want %v", got, want)
+	}
+
+	active, err := svc.ProblemList(context.Background(), 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(active), []uint{6, 4, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("active: got %v, want %v", got, want)
+	}
+}
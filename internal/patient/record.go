@@ -0,0 +1,184 @@
+package patient
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrRecordNotFound        = errors.New("medical record not found")
+	ErrInvalidRecordType     = errors.New("invalid medical record type")
+	ErrRecordPayloadMismatch = errors.New("record payload does not match entry type")
+	ErrInvalidRecordPayload  = errors.New("invalid medical record payload")
+	ErrRecordOutOfScope      = errors.New("record type outside authorization scope")
+)
+
+const (
+	defaultRecordLimit = 50
+	maxRecordLimit     = 200
+)
+
+// payload retorna o conteúdo tipado preenchido; nil para NOTE.
+func (r *MedicalRecord) payload() any {
+	switch {
+	case r.PreventionData != nil:
+		return r.PreventionData
+	case r.ProblemData != nil:
+		return r.ProblemData
+	case r.ExamData != nil:
+		return r.ExamData
+	case r.PhysicalExamData != nil:
+		return r.PhysicalExamData
+	}
+	return nil
+}
+
+func (r *MedicalRecord) payloadID() uint {
+	switch p := r.payload().(type) {
+	case *Prevention:
+		return p.ID
+	case *Problem:
+		return p.ID
+	case *Exam:
+		return p.ID
+	case *PhysicalExam:
+		return p.ID
+	}
+	return 0
+}
+
+// validatePayload exige exatamente o conteúdo correspondente ao EntryType.
+// NOTE não tem conteúdo tipado: o texto vai em Description.
+func (r *MedicalRecord) validatePayload() error {
+	if !r.EntryType.Valid() {
+		return ErrInvalidRecordType
+	}
+	present := map[MedicalRecordType]bool{
+		RecordTypePrevention:   r.PreventionData != nil,
+		RecordTypeProblem:      r.ProblemData != nil,
+		RecordTypeExam:         r.ExamData != nil,
+		RecordTypePhysicalExam: r.PhysicalExamData != nil,
+	}
+	for t, ok := range present {
+		if ok != (t == r.EntryType) {
+			return ErrRecordPayloadMismatch
+		}
+	}
+
+	switch r.EntryType {
+	case RecordTypePrevention:
+		if strings.TrimSpace(r.PreventionData.Name) == "" {
+			return ErrInvalidRecordPayload
+		}
+	case RecordTypeProblem:
+		return r.ProblemData.normalize(r.Date)
+	case RecordTypeExam:
+		if strings.TrimSpace(r.ExamData.Name) == "" {
+			return ErrInvalidRecordPayload
+		}
+	case RecordTypePhysicalExam:
+		return r.PhysicalExamData.validate()
+	case RecordTypeNote:
+		if strings.TrimSpace(r.Description) == "" {
+			return ErrInvalidRecordPayload
+		}
+	}
+	return nil
+}
+
+// CreateRecord adiciona um registro à linha do tempo do paciente.
+func (s *service) CreateRecord(ctx context.Context, authorID, patientID uint, record *MedicalRecord) (*MedicalRecord, error) {
+	if err := record.validatePayload(); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindByUserID(ctx, patientID); err != nil {
+		return nil, err
+	}
+
+	record.ID = 0
+	record.UserID = patientID
+	record.CreatedBy = authorID
+	bindPayload(record, 0, 0)
+
+	if err := s.repo.CreateMedicalRecord(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ListRecords retorna a linha do tempo paginada e o total do filtro.
+func (s *service) ListRecords(ctx context.Context, patientID uint, filter RecordFilter) ([]MedicalRecord, int64, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultRecordLimit
+	}
+	if filter.Limit > maxRecordLimit {
+		filter.Limit = maxRecordLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	for _, t := range filter.Types {
+		if !t.Valid() {
+			return nil, 0, ErrInvalidRecordType
+		}
+	}
+	return s.repo.FindMedicalRecords(ctx, patientID, filter)
+}
+
+func (s *service) GetRecord(ctx context.Context, patientID, recordID uint) (*MedicalRecord, error) {
+	record, err := s.repo.FindMedicalRecord(ctx, patientID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrRecordNotFound
+	}
+	return record, nil
+}
+
+// UpdateRecord substitui título, descrição, data e conteúdo tipado. O
+// EntryType não muda: para outro tipo, crie outro registro.
+func (s *service) UpdateRecord(ctx context.Context, patientID, recordID uint, changes *MedicalRecord) (*MedicalRecord, error) {
+	record, err := s.GetRecord(ctx, patientID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if changes.EntryType != record.EntryType {
+		return nil, ErrRecordPayloadMismatch
+	}
+	if err := changes.validatePayload(); err != nil {
+		return nil, err
+	}
+
+	// mantém a identidade do conteúdo tipado já gravado
+	payloadID := record.payloadID()
+
+	record.Title = changes.Title
+	record.Description = changes.Description
+	record.Date = changes.Date
+	record.PreventionData = changes.PreventionData
+	record.ProblemData = changes.ProblemData
+	record.ExamData = changes.ExamData
+	record.PhysicalExamData = changes.PhysicalExamData
+	bindPayload(record, record.ID, payloadID)
+
+	if err := s.repo.UpdateMedicalRecord(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// bindPayload liga o conteúdo tipado ao registro e fixa o id dele (0 = novo).
+func bindPayload(record *MedicalRecord, recordID, payloadID uint) {
+	switch p := record.payload().(type) {
+	case *Prevention:
+		p.ID, p.MedicalRecordID = payloadID, recordID
+	case *Problem:
+		p.ID, p.MedicalRecordID = payloadID, recordID
+	case *Exam:
+		p.ID, p.MedicalRecordID = payloadID, recordID
+	case *PhysicalExam:
+		p.ID, p.MedicalRecordID = payloadID, recordID
+	}
+}
@@ -3,16 +3,26 @@ package patient
 import (
 	"context"
 	"errors"
+	"time"
 
+	"sonnda-api/internal/user"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
-	ErrPatientNotFound = errors.New("patient not found")
+	ErrPatientNotFound       = errors.New("patient not found")
+	ErrAuthorizationConflict = errors.New("authorization changed concurrently")
+	ErrEmailTaken            = errors.New("email already registered")
+	ErrCPFTaken              = errors.New("cpf already registered")
+	ErrCNSTaken              = errors.New("cns already registered")
 )
 
 type Repository interface {
 	// Operações CRUD básicas
+	Register(ctx context.Context, u *user.User, patient *PatientProfile) error
 	Create(ctx context.Context, patient *PatientProfile) error
 	Update(ctx context.Context, patient *PatientProfile) error
 	Delete(ctx context.Context, id uint) error
@@ -21,15 +31,31 @@ type Repository interface {
 	// Finders
 	FindByUserID(ctx context.Context, userID uint) (*PatientProfile, error)
 	FindByCPF(ctx context.Context, cpf string) (*PatientProfile, error)
+	FindByCNS(ctx context.Context, cns string) (*PatientProfile, error)
 
 	// Relacionamentos
 	FindAuthorizations(ctx context.Context, patientID uint) ([]Authorization, error)
+	FindAuthorizationByID(ctx context.Context, id uint) (*Authorization, error)
+	FindOpenAuthorization(ctx context.Context, userID, patientID uint) (*Authorization, error)
+	FindAuthorizationsByUser(ctx context.Context, userID uint) ([]Authorization, error)
+	FindActiveAuthorizations(ctx context.Context, userID, patientID uint) ([]Authorization, error)
 	CreateAuthorization(ctx context.Context, auth *Authorization) error
 	UpdateAuthorization(ctx context.Context, auth *Authorization) error
+	TransitionAuthorization(ctx context.Context, auth *Authorization, from AuthStatus, entry *AuthorizationHistory) error
+	FindExpiredAuthorizations(ctx context.Context, now time.Time, limit int) ([]Authorization, error)
+
+	// Acesso de emergência
+	CreateEmergencyAccess(ctx context.Context, auth *Authorization, entry *EmergencyAccessLog) error
+	MarkEmergencyNotified(ctx context.Context, logID uint, at time.Time) error
+	FindUserEmail(ctx context.Context, userID uint) (string, error)
 
 	// Medical Records
 	CreateMedicalRecord(ctx context.Context, record *MedicalRecord) error
-	FindMedicalRecords(ctx context.Context, patientID uint) ([]MedicalRecord, error)
+	UpdateMedicalRecord(ctx context.Context, record *MedicalRecord) error
+	FindMedicalRecord(ctx context.Context, patientID, recordID uint) (*MedicalRecord, error)
+	FindMedicalRecords(ctx context.Context, patientID uint, filter RecordFilter) ([]MedicalRecord, int64, error)
+	FindVitals(ctx context.Context, patientID uint, from, to *time.Time) ([]MedicalRecord, error)
+	FindProblems(ctx context.Context, patientID uint) ([]MedicalRecord, error)
 }
 
 type repository struct {
@@ -40,6 +66,36 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// Register cria o usuário PATIENT e o perfil vinculado na mesma transação.
+// Violações de unicidade viram ErrEmailTaken, ErrCPFTaken ou ErrCNSTaken.
+func (r *repository) Register(ctx context.Context, u *user.User, patient *PatientProfile) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		patient.UserID = u.ID
+		return tx.Create(patient).Error
+	})
+	return uniqueViolation(err)
+}
+
+// uniqueViolation traduz violações dos índices únicos de e-mail, CPF e CNS.
+func uniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return err
+	}
+	switch pgErr.ConstraintName {
+	case "idx_users_email":
+		return ErrEmailTaken
+	case "idx_patient_profiles_cpf":
+		return ErrCPFTaken
+	case "idx_patient_profiles_cns":
+		return ErrCNSTaken
+	}
+	return err
+}
+
 // Create: Cadastra um novo usuário
 func (r *repository) Create(ctx context.Context, patient *PatientProfile) error {
 	return r.db.WithContext(ctx).Create(patient).Error
@@ -47,7 +103,8 @@ func (r *repository) Create(ctx context.Context, patient *PatientProfile) error
 
 // Update: Atualiza dados do paciente
 func (r *repository) Update(ctx context.Context, patient *PatientProfile) error {
-	return r.db.WithContext(ctx).Save(patient).Error
+	err := r.db.WithContext(ctx).Omit(clause.Associations).Save(patient).Error
+	return uniqueViolation(err)
 }
 
 // Delete remove paciente (soft delete se configurado)
@@ -65,13 +122,10 @@ func (r *repository) List(ctx context.Context, limit, offset int) ([]PatientProf
 	return patients, err
 }
 
-// FindByUserID busca paciente por user_id
+// FindByUserID busca paciente por user_id, sem os relacionamentos
 func (r *repository) FindByUserID(ctx context.Context, userID uint) (*PatientProfile, error) {
 	var p PatientProfile
-	if err := r.db.WithContext(ctx).
-		Preload("Authorizations").
-		Preload("MedicalRecords").
-		First(&p, "user_id = ?", userID).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&p, "user_id = ?", userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrPatientNotFound
 		}
@@ -91,6 +145,17 @@ func (r *repository) FindByCPF(ctx context.Context, cpf string) (*PatientProfile
 	return &p, nil
 }
 
+func (r *repository) FindByCNS(ctx context.Context, cns string) (*PatientProfile, error) {
+	var p PatientProfile
+	if err := r.db.WithContext(ctx).First(&p, "cns = ?", cns).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
 // FindAuthorizations retorna todas as autorizações do paciente
 func (r *repository) FindAuthorizations(ctx context.Context, patientID uint) ([]Authorization, error) {
 	var auths []Authorization
@@ -101,11 +166,37 @@ func (r *repository) FindAuthorizations(ctx context.Context, patientID uint) ([]
 	return auths, err
 }
 
-// FindAuthorizationByUser busca autorização específica
-func (r *repository) FindAuthorizationByUser(ctx context.Context, userID, patientID uint) (*Authorization, error) {
+// FindAuthorizationByID busca autorização com o histórico
+func (r *repository) FindAuthorizationByID(ctx context.Context, id uint) (*Authorization, error) {
 	var auth Authorization
 	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND patient_id = ?", userID, patientID).
+		Preload("History", func(db *gorm.DB) *gorm.DB { return db.Order("changed_at ASC") }).
+		First(&auth, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &auth, nil
+}
+
+// FindAuthorizationsByUser retorna as autorizações solicitadas pelo usuário
+func (r *repository) FindAuthorizationsByUser(ctx context.Context, userID uint) ([]Authorization, error) {
+	var auths []Authorization
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("requested_at DESC").
+		Find(&auths).Error
+	return auths, err
+}
+
+// FindOpenAuthorization busca um pedido pendente ou aprovado do usuário para o
+// paciente, mesmo que haja registros mais novos (ex.: emergência)
+func (r *repository) FindOpenAuthorization(ctx context.Context, userID, patientID uint) (*Authorization, error) {
+	var auth Authorization
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND patient_id = ? AND status IN ?", userID, patientID, openRequest).
 		Order("requested_at DESC").
 		First(&auth).Error
 	if err != nil {
@@ -117,6 +208,18 @@ func (r *repository) FindAuthorizationByUser(ctx context.Context, userID, patien
 	return &auth, nil
 }
 
+// FindActiveAuthorizations busca as autorizações vigentes (aprovadas ou de
+// emergência) do usuário para o paciente, da mais recente para a mais antiga
+func (r *repository) FindActiveAuthorizations(ctx context.Context, userID, patientID uint) ([]Authorization, error) {
+	var auths []Authorization
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND patient_id = ? AND status IN ? AND revoked_at IS NULL", userID, patientID, grantsAccess).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+		Order("requested_at DESC").
+		Find(&auths).Error
+	return auths, err
+}
+
 // CreateAuthorization cria nova autorização
 func (r *repository) CreateAuthorization(ctx context.Context, auth *Authorization) error {
 	return r.db.WithContext(ctx).Create(auth).Error
@@ -127,21 +230,165 @@ func (r *repository) UpdateAuthorization(ctx context.Context, auth *Authorizatio
 	return r.db.WithContext(ctx).Save(auth).Error
 }
 
+// TransitionAuthorization grava a mudança de status e a entrada do histórico
+// na mesma transação. Falha com ErrAuthorizationConflict se o status no banco
+// não for mais o esperado (outra requisição mudou antes).
+func (r *repository) TransitionAuthorization(ctx context.Context, auth *Authorization, from AuthStatus, entry *AuthorizationHistory) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(auth).
+			Where("status = ?", from).
+			Select("status", "approved_at", "denied_at", "revoked_at", "expires_at", "read_only", "record_types").
+			Updates(auth)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrAuthorizationConflict
+		}
+		entry.AuthorizationID = auth.ID
+		return tx.Create(entry).Error
+	})
+}
+
+// FindExpiredAuthorizations retorna autorizações abertas cujo prazo já venceu
+func (r *repository) FindExpiredAuthorizations(ctx context.Context, now time.Time, limit int) ([]Authorization, error) {
+	var auths []Authorization
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND expires_at IS NOT NULL AND expires_at <= ?", []AuthStatus{AuthPending, AuthApproved, AuthEmergency}, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&auths).Error
+	return auths, err
+}
+
+// CreateEmergencyAccess grava a autorização de emergência e o registro de
+// auditoria na mesma transação.
+func (r *repository) CreateEmergencyAccess(ctx context.Context, auth *Authorization, entry *EmergencyAccessLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(auth).Error; err != nil {
+			return err
+		}
+		entry.AuthorizationID = auth.ID
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *repository) MarkEmergencyNotified(ctx context.Context, logID uint, at time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&EmergencyAccessLog{}).
+		Where("id = ?", logID).
+		Update("patient_notified_at", at).Error
+}
+
+// FindUserEmail retorna o e-mail da conta do paciente
+func (r *repository) FindUserEmail(ctx context.Context, userID uint) (string, error) {
+	var u user.User
+	if err := r.db.WithContext(ctx).Select("email").First(&u, userID).Error; err != nil {
+		return "", err
+	}
+	return u.Email, nil
+}
+
 // CreateMedicalRecord cria registro médico
 func (r *repository) CreateMedicalRecord(ctx context.Context, record *MedicalRecord) error {
 	return r.db.WithContext(ctx).Create(record).Error
 }
 
-// FindMedicalRecords retorna histórico médico do paciente
-func (r *repository) FindMedicalRecords(ctx context.Context, patientID uint) ([]MedicalRecord, error) {
+// RecordFilter filtra a linha do tempo. Types vazio = todos; To é exclusivo.
+type RecordFilter struct {
+	Types  []MedicalRecordType
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// FindMedicalRecords retorna a linha do tempo do paciente (mais recente
+// primeiro) e o total de registros do filtro, para paginação.
+func (r *repository) FindMedicalRecords(ctx context.Context, patientID uint, filter RecordFilter) ([]MedicalRecord, int64, error) {
+	q := r.db.WithContext(ctx).Model(&MedicalRecord{}).Where("user_id = ?", patientID)
+	if len(filter.Types) > 0 {
+		q = q.Where("entry_type IN ?", filter.Types)
+	}
+	if filter.From != nil {
+		q = q.Where("date >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		q = q.Where("date < ?", *filter.To)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var records []MedicalRecord
-	err := r.db.WithContext(ctx).
-		Where("patient_id = ?", patientID).
+	err := q.
 		Preload("PreventionData").
 		Preload("ProblemData").
 		Preload("ExamData").
 		Preload("PhysicalExamData").
-		Order("date DESC").
+		Order("date DESC, id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&records).Error
+	return records, total, err
+}
+
+// FindVitals retorna os exames físicos do paciente em ordem cronológica
+func (r *repository) FindVitals(ctx context.Context, patientID uint, from, to *time.Time) ([]MedicalRecord, error) {
+	q := r.db.WithContext(ctx).
+		Where("user_id = ? AND entry_type = ?", patientID, RecordTypePhysicalExam)
+	if from != nil {
+		q = q.Where("date >= ?", *from)
+	}
+	if to != nil {
+		q = q.Where("date < ?", *to)
+	}
+	var records []MedicalRecord
+	err := q.Preload("PhysicalExamData").Order("date ASC, id ASC").Find(&records).Error
+	return records, err
+}
+
+// FindProblems retorna os registros PROBLEM do paciente em ordem cronológica
+func (r *repository) FindProblems(ctx context.Context, patientID uint) ([]MedicalRecord, error) {
+	var records []MedicalRecord
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND entry_type = ?", patientID, RecordTypeProblem).
+		Preload("ProblemData").
+		Order("date ASC, id ASC").
 		Find(&records).Error
 	return records, err
 }
+
+// FindMedicalRecord busca um registro do paciente com o conteúdo tipado
+func (r *repository) FindMedicalRecord(ctx context.Context, patientID, recordID uint) (*MedicalRecord, error) {
+	var record MedicalRecord
+	err := r.db.WithContext(ctx).
+		Preload("PreventionData").
+		Preload("ProblemData").
+		Preload("ExamData").
+		Preload("PhysicalExamData").
+		Where("user_id = ?", patientID).
+		First(&record, recordID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// UpdateMedicalRecord salva o registro e o conteúdo tipado na mesma transação
+func (r *repository) UpdateMedicalRecord(ctx context.Context, record *MedicalRecord) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(record).Error; err != nil {
+			return err
+		}
+		if payload := record.payload(); payload != nil {
+			return tx.Save(payload).Error
+		}
+		return nil
+	})
+}
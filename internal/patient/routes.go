@@ -2,16 +2,20 @@ package patient
 
 import (
 	"sonnda-api/internal/database"
+	"sonnda-api/internal/mail"
 	"sonnda-api/internal/middleware"
-	"sonnda-api/internal/user"
+	"sonnda-api/internal/rbac"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Routes(rg *gin.RouterGroup) {
+// Routes registra as rotas do módulo. authenticate é o middleware do
+// auth.Authenticator, compartilhado por todos os grupos protegidos.
+func Routes(rg *gin.RouterGroup, authenticate gin.HandlerFunc, policy *rbac.Policy, mailer mail.Mailer, verification VerificationSender) {
 	repo := NewRepository(database.DB)
-	svc := NewService(repo)
+	svc := NewService(repo, mailer, verification)
 	handler := NewHandler(svc)
+	guard := NewAccessGuard(repo, policy)
 
 	patients := rg.Group("/patients")
 	{
@@ -19,10 +23,52 @@ func Routes(rg *gin.RouterGroup) {
 		patients.POST("/register", handler.Register)
 
 		protected := patients.Group("")
-		protected.Use(middleware.JWTAuthMiddleware())
-		protected.Use(middleware.RequireRole(user.RolePatient))
+		protected.Use(authenticate)
+		protected.Use(policy.Require(rbac.PermProfileSelf))
 
 		// protegida
-		protected.GET("/me", middleware.JWTAuthMiddleware(), handler.Me)
+		protected.GET("/me", handler.Me)
+		protected.PUT("/me", handler.UpdateMe)
+		protected.GET("/me/authorizations", handler.ListMyAuthorizations)
+		protected.POST("/me/authorizations/:authId/approve", handler.ApproveAccess)
+		protected.POST("/me/authorizations/:authId/deny", handler.DenyAccess)
+
+		// dados de um paciente: exigem consentimento (ou ser o próprio paciente/admin)
+		record := patients.Group("/:id")
+		record.Use(authenticate)
+
+		readRecords := guard.Require("id", rbac.PermPatientRead, rbac.PermRecordRead)
+		writeRecords := guard.Require("id", rbac.PermPatientRead, rbac.PermRecordWrite)
+
+		record.GET("", guard.Require("id", rbac.PermPatientRead), handler.GetPatient)
+
+		// linha do tempo do prontuário
+		record.GET("/records", readRecords, handler.ListRecords)
+		record.POST("/records", writeRecords, handler.CreateRecord)
+		record.GET("/records/:recordId", readRecords, handler.GetRecord)
+		record.PUT("/records/:recordId", writeRecords, handler.UpdateRecord)
+		record.GET("/vitals", readRecords, handler.VitalsTrend)
+		record.GET("/problems", readRecords, handler.ProblemList)
+		record.GET("/prevention/schedule", readRecords, handler.PreventionSchedule)
+
+		// acesso de emergência ("break-glass"): sem consentimento, só a
+		// profissão médico (enfermagem e ACS também têm a role DOCTOR)
+		patients.POST("/:id/emergency-access",
+			authenticate,
+			middleware.RequireDoctor(),
+			policy.Require(rbac.PermEmergencyAccess, rbac.PermPatientRead, rbac.PermRecordRead),
+			handler.EmergencyAccess,
+		)
+	}
+
+	// pedidos de acesso feitos por profissionais
+	authorizations := rg.Group("/authorizations")
+	authorizations.Use(authenticate)
+	{
+		authorizations.POST("", policy.Require(rbac.PermPatientRead), handler.RequestAccess)
+		authorizations.GET("/requested", policy.Require(rbac.PermPatientRead), handler.ListRequested)
+
+		// paciente ou solicitante
+		authorizations.POST("/:authId/revoke", handler.RevokeAccess)
 	}
 }
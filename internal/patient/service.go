@@ -1,11 +1,202 @@
 package patient
 
+import (
+	"context"
+	"log"
+	"time"
+
+	"sonnda-api/internal/brdocs"
+	"sonnda-api/internal/mail"
+	"sonnda-api/internal/user"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
 type Service interface {
+	Register(ctx context.Context, in RegisterInput) (*PatientProfile, error)
+	GetProfile(ctx context.Context, patientID uint) (*PatientProfile, error)
+	GetOwnProfile(ctx context.Context, patientID uint) (*PatientProfile, error)
+	UpdateProfile(ctx context.Context, patientID uint, in UpdateProfileInput) (*PatientProfile, error)
+
+	// Prontuário
+	CreateRecord(ctx context.Context, authorID, patientID uint, record *MedicalRecord) (*MedicalRecord, error)
+	ListRecords(ctx context.Context, patientID uint, filter RecordFilter) ([]MedicalRecord, int64, error)
+	GetRecord(ctx context.Context, patientID, recordID uint) (*MedicalRecord, error)
+	UpdateRecord(ctx context.Context, patientID, recordID uint, changes *MedicalRecord) (*MedicalRecord, error)
+	VitalsTrend(ctx context.Context, patientID uint, from, to *time.Time) ([]VitalsPoint, error)
+	ProblemList(ctx context.Context, patientID uint, includeAll bool) ([]ProblemListEntry, error)
+	PreventionSchedule(ctx context.Context, patientID uint) (*PreventionSchedule, error)
+
+	// Consentimento
+	RequestAccess(ctx context.Context, requesterID uint, cpf, reason string, scope AuthorizationScope) (*Authorization, error)
+	ListPatientAuthorizations(ctx context.Context, patientID uint, status AuthStatus) ([]Authorization, error)
+	ListRequestedAuthorizations(ctx context.Context, requesterID uint) ([]Authorization, error)
+	ApproveAccess(ctx context.Context, patientID, authID uint, reason string, scope *AuthorizationScope) (*Authorization, error)
+	DenyAccess(ctx context.Context, patientID, authID uint, reason string) (*Authorization, error)
+	RevokeAccess(ctx context.Context, actorID, authID uint, reason string) (*Authorization, error)
+	ExpireAuthorizations(ctx context.Context, now time.Time) (int, error)
+	EmergencyAccess(ctx context.Context, doctorID, patientID uint, justification, ip, userAgent string) (*Authorization, error)
+}
+
+// VerificationSender envia o link de confirmação de e-mail da conta criada
+// no cadastro (auth.VerificationSender).
+type VerificationSender interface {
+	Send(ctx context.Context, u *user.User) error
 }
+
 type service struct {
-	repo Repository
+	repo         Repository
+	mailer       mail.Mailer
+	verification VerificationSender
+}
+
+func NewService(repo Repository, mailer mail.Mailer, verification VerificationSender) Service {
+	return &service{repo: repo, mailer: mailer, verification: verification}
+}
+
+// RegisterInput são os dados do cadastro do paciente.
+type RegisterInput struct {
+	Email     string
+	Password  string
+	FullName  string
+	CPF       string
+	CNS       *string
+	BirthDate time.Time
+	Gender    Gender
+	Race      Race
+	Phone     *string
+}
+
+// UpdateProfileInput traz só os campos alterados (nil = mantém). CPF não muda.
+type UpdateProfileInput struct {
+	FullName  *string
+	CNS       *string
+	BirthDate *time.Time
+	Gender    *Gender
+	Race      *Race
+	Phone     *string
+	AvatarURL *string
+}
+
+// Register cria a conta PATIENT e o perfil juntos e envia a verificação de
+// e-mail, como o cadastro de /auth/register.
+func (s *service) Register(ctx context.Context, in RegisterInput) (*PatientProfile, error) {
+	in.CPF = brdocs.NormalizeCPF(in.CPF)
+	if in.CNS != nil {
+		cns := brdocs.NormalizeCNS(*in.CNS)
+		in.CNS = &cns
+	}
+	if existing, err := s.repo.FindByCPF(ctx, in.CPF); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, ErrCPFTaken
+	}
+	if in.CNS != nil {
+		if existing, err := s.repo.FindByCNS(ctx, *in.CNS); err != nil {
+			return nil, err
+		} else if existing != nil {
+			return nil, ErrCNSTaken
+		}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &user.User{
+		Email:        in.Email,
+		PasswordHash: string(hash),
+		Role:         user.RolePatient,
+	}
+	p := &PatientProfile{
+		CPF:       in.CPF,
+		CNS:       in.CNS,
+		FullName:  in.FullName,
+		BirthDate: in.BirthDate,
+		Gender:    in.Gender,
+		Race:      in.Race,
+		Phone:     in.Phone,
+	}
+	if p.Gender == "" {
+		p.Gender = GenderUnknown
+	}
+	if p.Race == "" {
+		p.Race = RaceUnknown
+	}
+
+	// e-mail repetido (e corridas de CPF/CNS) caem nos índices únicos
+	if err := s.repo.Register(ctx, u, p); err != nil {
+		return nil, err
+	}
+
+	// falha no envio não impede o cadastro: o usuário pode pedir reenvio
+	if err := s.verification.Send(ctx, u); err != nil {
+		log.Printf("⚠️  Falha ao enviar verificação de e-mail para usuário %d: %v", u.ID, err)
+	}
+	return p, nil
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+// GetProfile retorna o perfil do paciente. O acesso já foi validado pelo AccessGuard.
+// Terceiros veem só o perfil: a lista de autorizações é do paciente.
+func (s *service) GetProfile(ctx context.Context, patientID uint) (*PatientProfile, error) {
+	return s.repo.FindByUserID(ctx, patientID)
+}
+
+// GetOwnProfile retorna o perfil do próprio paciente com suas autorizações.
+func (s *service) GetOwnProfile(ctx context.Context, patientID uint) (*PatientProfile, error) {
+	p, err := s.repo.FindByUserID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Authorizations, err = s.repo.FindAuthorizations(ctx, patientID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UpdateProfile altera o perfil do próprio paciente.
+func (s *service) UpdateProfile(ctx context.Context, patientID uint, in UpdateProfileInput) (*PatientProfile, error) {
+	p, err := s.repo.FindByUserID(ctx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.CNS != nil {
+		cns := brdocs.NormalizeCNS(*in.CNS)
+		in.CNS = &cns
+	}
+	if in.CNS != nil && (p.CNS == nil || *p.CNS != *in.CNS) {
+		existing, err := s.repo.FindByCNS(ctx, *in.CNS)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.UserID != patientID {
+			return nil, ErrCNSTaken
+		}
+		p.CNS = in.CNS
+	}
+	if in.FullName != nil {
+		p.FullName = *in.FullName
+	}
+	if in.BirthDate != nil {
+		p.BirthDate = *in.BirthDate
+	}
+	if in.Gender != nil {
+		p.Gender = *in.Gender
+	}
+	if in.Race != nil {
+		p.Race = *in.Race
+	}
+	if in.Phone != nil {
+		p.Phone = in.Phone
+	}
+	if in.AvatarURL != nil {
+		p.AvatarURL = *in.AvatarURL
+	}
+
+	if err := s.repo.Update(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
@@ -0,0 +1,122 @@
+package patient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// vitalRange é a faixa plausível de um sinal vital, na unidade canônica do
+// campo. Valores fora dela quase sempre são erro de digitação ou de unidade
+// (ex.: altura em metros, febre em °F): a checagem só recusa, não converte, e
+// o erro traz a unidade esperada para o cliente corrigir.
+type vitalRange struct {
+	Field    string
+	Unit     string
+	Min, Max float64
+}
+
+var (
+	rangeSystolic    = vitalRange{"systolic_bp", "mmHg", 50, 300}
+	rangeDiastolic   = vitalRange{"diastolic_bp", "mmHg", 20, 200}
+	rangeHeartRate   = vitalRange{"heart_rate", "bpm", 20, 300}
+	rangeRespiratory = vitalRange{"respiratory_rate", "irpm", 4, 80}
+	rangeTemperature = vitalRange{"temperature_c", "°C", 30, 45}
+	rangeSpO2        = vitalRange{"spo2", "%", 50, 100}
+	rangeWeight      = vitalRange{"weight_kg", "kg", 0.3, 500}
+	rangeHeight      = vitalRange{"height_cm", "cm", 20, 260}
+	rangeWaist       = vitalRange{"waist_cm", "cm", 20, 250}
+)
+
+// VitalRangeError aponta o sinal vital fora da faixa plausível.
+type VitalRangeError struct {
+	vitalRange
+	Value float64
+}
+
+func (e *VitalRangeError) Error() string {
+	return fmt.Sprintf("%s %.1f out of plausible range %.1f-%.1f %s", e.Field, e.Value, e.Min, e.Max, e.Unit)
+}
+
+func (r vitalRange) check(v *float64) error {
+	if v != nil && (*v < r.Min || *v > r.Max || math.IsNaN(*v)) {
+		return &VitalRangeError{vitalRange: r, Value: *v}
+	}
+	return nil
+}
+
+func (r vitalRange) checkInt(v *int) error {
+	if v == nil {
+		return nil
+	}
+	f := float64(*v)
+	return r.check(&f)
+}
+
+// validate confere faixas e coerência entre os sinais. Pelo menos um sinal
+// precisa ter sido aferido.
+func (p *PhysicalExam) validate() error {
+	checks := []error{
+		rangeSystolic.checkInt(p.SystolicBP),
+		rangeDiastolic.checkInt(p.DiastolicBP),
+		rangeHeartRate.checkInt(p.HeartRate),
+		rangeRespiratory.checkInt(p.RespiratoryRate),
+		rangeTemperature.check(p.TemperatureC),
+		rangeSpO2.checkInt(p.SpO2),
+		rangeWeight.check(p.WeightKg),
+		rangeHeight.check(p.HeightCm),
+		rangeWaist.check(p.WaistCm),
+	}
+	for _, err := range checks {
+		if err != nil {
+			return err
+		}
+	}
+
+	if (p.SystolicBP == nil) != (p.DiastolicBP == nil) {
+		return ErrInvalidRecordPayload
+	}
+	if p.SystolicBP != nil && *p.DiastolicBP >= *p.SystolicBP {
+		return ErrInvalidRecordPayload
+	}
+	if p.SystolicBP == nil && p.HeartRate == nil && p.RespiratoryRate == nil &&
+		p.TemperatureC == nil && p.SpO2 == nil && p.WeightKg == nil &&
+		p.HeightCm == nil && p.WaistCm == nil {
+		return ErrInvalidRecordPayload
+	}
+	return nil
+}
+
+// computeBMI calcula o IMC (kg/m²) com uma casa decimal; nil sem peso e altura.
+func computeBMI(weightKg, heightCm *float64) *float64 {
+	if weightKg == nil || heightCm == nil || *heightCm <= 0 {
+		return nil
+	}
+	m := *heightCm / 100
+	bmi := math.Round(*weightKg/(m*m)*10) / 10
+	return &bmi
+}
+
+// VitalsPoint é uma aferição na série temporal de sinais vitais.
+type VitalsPoint struct {
+	RecordID uint          `json:"record_id"`
+	Date     time.Time     `json:"date"`
+	Vitals   *PhysicalExam `json:"vitals"`
+}
+
+// VitalsTrend retorna as aferições do paciente em ordem cronológica.
+func (s *service) VitalsTrend(ctx context.Context, patientID uint, from, to *time.Time) ([]VitalsPoint, error) {
+	records, err := s.repo.FindVitals(ctx, patientID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]VitalsPoint, 0, len(records))
+	for _, r := range records {
+		if r.PhysicalExamData == nil {
+			continue
+		}
+		points = append(points, VitalsPoint{RecordID: r.ID, Date: r.Date, Vitals: r.PhysicalExamData})
+	}
+	return points, nil
+}
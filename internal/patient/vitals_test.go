@@ -0,0 +1,53 @@
+package patient
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPhysicalExamValidate(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	i := func(v int) *int { return &v }
+
+	tests := []struct {
+		name      string
+		exam      PhysicalExam
+		wantField string // campo do VitalRangeError; vazio = outro erro ou nil
+		wantErr   error
+	}{
+		{"pressão e peso", PhysicalExam{SystolicBP: i(120), DiastolicBP: i(80), WeightKg: f(70)}, "", nil},
+		{"só temperatura", PhysicalExam{TemperatureC: f(37.8)}, "", nil},
+		// sem conversão de unidade: °F, metros e libras são recusados
+		{"temperatura em °F", PhysicalExam{TemperatureC: f(100.4)}, "temperature_c", nil},
+		{"altura em metros", PhysicalExam{HeightCm: f(1.75)}, "height_cm", nil},
+		{"peso em libras", PhysicalExam{WeightKg: f(600)}, "weight_kg", nil},
+		{"spo2 acima de 100", PhysicalExam{SpO2: i(101)}, "spo2", nil},
+		{"só sistólica", PhysicalExam{SystolicBP: i(120)}, "", ErrInvalidRecordPayload},
+		{"diastólica maior", PhysicalExam{SystolicBP: i(80), DiastolicBP: i(120)}, "", ErrInvalidRecordPayload},
+		{"nenhum sinal", PhysicalExam{}, "", ErrInvalidRecordPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.exam.validate()
+			var rangeErr *VitalRangeError
+			switch {
+			case tt.wantField != "":
+				if !errors.As(err, &rangeErr) || rangeErr.Field != tt.wantField {
+					t.Errorf("got %v, want range error on %s", err, tt.wantField)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComputeBMI(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	if got := computeBMI(f(70), f(175)); got == nil || *got != 22.9 {
+		t.Errorf("got %v, want 22.9", got)
+	}
+	if got := computeBMI(f(70), nil); got != nil {
+		t.Errorf("without height: got %v, want nil", *got)
+	}
+}
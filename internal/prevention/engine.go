@@ -0,0 +1,182 @@
+// Package prevention calcula o calendário de cuidados preventivos (rastreios e
+// vacinas) a partir de regras por idade e sexo definidas em arquivo.
+package prevention
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"sonnda-api/internal/textfold"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rules.yaml
+var bundled []byte
+
+type Status string
+
+const (
+	StatusDue      Status = "DUE"        // nunca realizado
+	StatusOverdue  Status = "OVERDUE"    // realizado, mas o intervalo venceu
+	StatusUpToDate Status = "UP_TO_DATE" // em dia
+)
+
+// Rules é um conjunto versionado de regras.
+type Rules struct {
+	Version string `yaml:"version" json:"version"`
+	Source  string `yaml:"source" json:"source"`
+	Rules   []Rule `yaml:"rules" json:"rules"`
+}
+
+type Rule struct {
+	ID             string   `yaml:"id" json:"id"`
+	Name           string   `yaml:"name" json:"name"`
+	Match          []string `yaml:"match" json:"match"`
+	Sex            string   `yaml:"sex" json:"sex,omitempty"`
+	MinAge         int      `yaml:"min_age" json:"min_age"`
+	MaxAge         int      `yaml:"max_age" json:"max_age,omitempty"`
+	IntervalMonths int      `yaml:"interval_months" json:"interval_months,omitempty"`
+	Once           bool     `yaml:"once" json:"once,omitempty"`
+}
+
+// Event é um cuidado já registrado (nome ou sigla do registro PREVENTION).
+type Event struct {
+	Name string
+	Date time.Time
+}
+
+// Item é a situação de uma regra para o paciente.
+type Item struct {
+	RuleID   string     `json:"rule_id"`
+	Name     string     `json:"name"`
+	Status   Status     `json:"status"`
+	LastDone *time.Time `json:"last_done,omitempty"`
+	NextDue  *time.Time `json:"next_due,omitempty"`
+}
+
+// Parse lê e valida regras em YAML (JSON também é YAML válido).
+func Parse(data []byte) (*Rules, error) {
+	var rules Rules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	if rules.Version == "" {
+		return nil, errors.New("prevention: rules file without version")
+	}
+	seen := make(map[string]bool)
+	for i := range rules.Rules {
+		r := &rules.Rules[i]
+		switch {
+		case r.ID == "" || seen[r.ID]:
+			return nil, fmt.Errorf("prevention: rule %d: missing or duplicated id", i)
+		case len(r.Match) == 0:
+			return nil, fmt.Errorf("prevention: rule %s: empty match", r.ID)
+		case !r.Once && r.IntervalMonths <= 0:
+			return nil, fmt.Errorf("prevention: rule %s: needs interval_months or once", r.ID)
+		case r.Sex != "" && r.Sex != "FEMALE" && r.Sex != "MALE":
+			return nil, fmt.Errorf("prevention: rule %s: invalid sex %q", r.ID, r.Sex)
+		}
+		seen[r.ID] = true
+		for j, m := range r.Match {
+			r.Match[j] = textfold.Fold(strings.TrimSpace(m))
+		}
+	}
+	return &rules, nil
+}
+
+var (
+	defaultOnce  sync.Once
+	defaultRules *Rules
+	defaultErr   error
+)
+
+// Default retorna as regras de PREVENTION_RULES, se definido, ou as embutidas.
+func Default() (*Rules, error) {
+	defaultOnce.Do(func() {
+		data := bundled
+		if path := os.Getenv("PREVENTION_RULES"); path != "" {
+			if data, defaultErr = os.ReadFile(path); defaultErr != nil {
+				return
+			}
+		}
+		defaultRules, defaultErr = Parse(data)
+	})
+	return defaultRules, defaultErr
+}
+
+// Schedule aplica as regras elegíveis pela idade em now e pelo sexo. Sexo
+// diferente de FEMALE/MALE recebe também as regras específicas de sexo: é
+// melhor o profissional descartar um item do que deixar de ver um rastreio.
+func (r *Rules) Schedule(birthDate time.Time, sex string, events []Event, now time.Time) []Item {
+	age := AgeAt(birthDate, now)
+
+	items := make([]Item, 0, len(r.Rules))
+	for _, rule := range r.Rules {
+		if age < rule.MinAge || (rule.MaxAge > 0 && age > rule.MaxAge) {
+			continue
+		}
+		if rule.Sex != "" && (sex == "FEMALE" || sex == "MALE") && rule.Sex != sex {
+			continue
+		}
+
+		item := Item{RuleID: rule.ID, Name: rule.Name, Status: StatusDue}
+		if last := rule.lastDone(events); last != nil {
+			item.LastDone = last
+			if rule.Once {
+				item.Status = StatusUpToDate
+			} else {
+				next := last.AddDate(0, rule.IntervalMonths, 0)
+				item.NextDue = &next
+				item.Status = StatusUpToDate
+				if !next.After(now) {
+					item.Status = StatusOverdue
+				}
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (rule Rule) lastDone(events []Event) *time.Time {
+	var last *time.Time
+	for _, e := range events {
+		if !rule.matches(e.Name) {
+			continue
+		}
+		if last == nil || e.Date.After(*last) {
+			d := e.Date
+			last = &d
+		}
+	}
+	return last
+}
+
+// matches compara palavras inteiras: o termo "dt" não casa com "dtpa".
+func (rule Rule) matches(name string) bool {
+	words := " " + strings.Join(strings.FieldsFunc(textfold.Fold(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ") + " "
+	for _, m := range rule.Match {
+		if strings.Contains(words, " "+m+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// AgeAt retorna a idade em anos completos.
+func AgeAt(birthDate, now time.Time) int {
+	age := now.Year() - birthDate.Year()
+	if now.Month() < birthDate.Month() || (now.Month() == birthDate.Month() && now.Day() < birthDate.Day()) {
+		age--
+	}
+	return age
+}
@@ -0,0 +1,208 @@
+package prevention
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+const testRules = `
+version: "test"
+rules:
+  - id: mamografia
+    name: Mamografia
+    match: [mamografia]
+    sex: FEMALE
+    min_age: 50
+    max_age: 69
+    interval_months: 24
+  - id: psa
+    name: PSA
+    match: [psa]
+    sex: MALE
+    min_age: 50
+    interval_months: 12
+  - id: dt
+    name: Dupla adulto
+    match: [" DT ", "dupla adulto"]
+    min_age: 20
+    interval_months: 120
+  - id: hepatite-b
+    name: Hepatite B
+    match: [hepatite b]
+    once: true
+`
+
+func mustParse(t *testing.T, data string) *Rules {
+	t.Helper()
+	rules, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+func byRule(items []Item) map[string]Item {
+	out := make(map[string]Item, len(items))
+	for _, it := range items {
+		out[it.RuleID] = it
+	}
+	return out
+}
+
+func TestParseBundled(t *testing.T) {
+	if _, err := Parse(bundled); err != nil {
+		t.Fatalf("bundled rules: %v", err)
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"sem versão", "rules: [{id: a, match: [x], once: true}]"},
+		{"sem id", "version: v\nrules: [{match: [x], once: true}]"},
+		{"id repetido", "version: v\nrules: [{id: a, match: [x], once: true}, {id: a, match: [y], once: true}]"},
+		{"sem match", "version: v\nrules: [{id: a, once: true}]"},
+		{"sem intervalo", "version: v\nrules: [{id: a, match: [x]}]"},
+		{"sexo inválido", "version: v\nrules: [{id: a, match: [x], once: true, sex: F}]"},
+		{"yaml inválido", "version: [v"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt.data)); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestScheduleStatus(t *testing.T) {
+	rules := mustParse(t, testRules)
+	now := date(2026, 6, 15)
+	birth := date(1970, 1, 10) // 56 anos
+
+	tests := []struct {
+		name   string
+		events []Event
+		rule   string
+		want   Status
+	}{
+		{"nunca realizado", nil, "mamografia", StatusDue},
+		{"em dia", []Event{{"Mamografia bilateral", date(2025, 3, 1)}}, "mamografia", StatusUpToDate},
+		{"vencido", []Event{{"Mamografia", date(2024, 6, 1)}}, "mamografia", StatusOverdue},
+		{"vence hoje", []Event{{"Mamografia", date(2024, 6, 15)}}, "mamografia", StatusOverdue},
+		{"usa o mais recente", []Event{{"Mamografia", date(2020, 1, 1)}, {"mamografia", date(2025, 1, 1)}}, "mamografia", StatusUpToDate},
+		{"acentos e caixa", []Event{{"VACINA DUPLA ADULTO", date(2020, 1, 1)}}, "dt", StatusUpToDate},
+		{"dose única realizada há anos", []Event{{"Hepatite B", date(1995, 1, 1)}}, "hepatite-b", StatusUpToDate},
+		{"dose única pendente", nil, "hepatite-b", StatusDue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := byRule(rules.Schedule(birth, "FEMALE", tt.events, now))
+			item, ok := items[tt.rule]
+			if !ok {
+				t.Fatalf("rule %s not scheduled", tt.rule)
+			}
+			if item.Status != tt.want {
+				t.Errorf("got %s, want %s", item.Status, tt.want)
+			}
+			if tt.want == StatusDue && item.LastDone != nil {
+				t.Errorf("due item with last done %v", item.LastDone)
+			}
+		})
+	}
+
+	once := byRule(rules.Schedule(birth, "FEMALE", []Event{{"Hepatite B", date(1995, 1, 1)}}, now))["hepatite-b"]
+	if once.NextDue != nil {
+		t.Errorf("once rule got next due %v", once.NextDue)
+	}
+	last := date(2025, 3, 1)
+	mamo := byRule(rules.Schedule(birth, "FEMALE", []Event{{"Mamografia", last}}, now))["mamografia"]
+	if mamo.NextDue == nil || !mamo.NextDue.Equal(date(2027, 3, 1)) {
+		t.Errorf("next due: got %v, want 2027-03-01", mamo.NextDue)
+	}
+}
+
+func TestScheduleEligibility(t *testing.T) {
+	rules := mustParse(t, testRules)
+	now := date(2026, 6, 15)
+
+	tests := []struct {
+		name  string
+		birth time.Time
+		sex   string
+		want  map[string]bool
+	}{
+		{"faz 50 hoje", date(1976, 6, 15), "FEMALE", map[string]bool{"mamografia": true, "psa": false}},
+		{"faz 50 amanhã", date(1976, 6, 16), "FEMALE", map[string]bool{"mamografia": false}},
+		{"69 anos", date(1957, 1, 1), "FEMALE", map[string]bool{"mamografia": true}},
+		{"fez 70 hoje", date(1956, 6, 15), "FEMALE", map[string]bool{"mamografia": false}},
+		{"homem de 60", date(1966, 1, 1), "MALE", map[string]bool{"mamografia": false, "psa": true}},
+		{"sexo OTHER recebe as duas", date(1966, 1, 1), "OTHER", map[string]bool{"mamografia": true, "psa": true}},
+		{"sexo UNKNOWN recebe as duas", date(1966, 1, 1), "UNKNOWN", map[string]bool{"mamografia": true, "psa": true}},
+		{"sem sexo informado", date(1966, 1, 1), "", map[string]bool{"mamografia": true, "psa": true}},
+		{"sem min_age", date(2026, 1, 1), "MALE", map[string]bool{"hepatite-b": true, "dt": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := byRule(rules.Schedule(tt.birth, tt.sex, nil, now))
+			for rule, want := range tt.want {
+				if _, got := items[rule]; got != want {
+					t.Errorf("%s: scheduled %v, want %v", rule, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRuleMatchesWholeWords(t *testing.T) {
+	rules := mustParse(t, testRules)
+	var dt Rule
+	for _, r := range rules.Rules {
+		if r.ID == "dt" {
+			dt = r
+		}
+	}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"dT", true},
+		{"Vacina dT (2ª dose)", true},
+		{"dT/dTpa", true},
+		{"dTpa", false},
+		{"dtpa gestante", false},
+		{"Dupla Adulto", true},
+		{"dupla", false},
+	}
+	for _, tt := range tests {
+		if got := dt.matches(tt.name); got != tt.want {
+			t.Errorf("matches(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAgeAt(t *testing.T) {
+	tests := []struct {
+		birth, now time.Time
+		want       int
+	}{
+		{date(1990, 6, 15), date(2026, 6, 14), 35},
+		{date(1990, 6, 15), date(2026, 6, 15), 36},
+		{date(1990, 6, 15), date(2026, 6, 16), 36},
+		{date(1990, 12, 31), date(2026, 1, 1), 35},
+		{date(2026, 6, 15), date(2026, 6, 15), 0},
+		// nascido em 29/02: completa anos em 01/03 nos anos não bissextos
+		{date(2000, 2, 29), date(2026, 2, 28), 25},
+		{date(2000, 2, 29), date(2026, 3, 1), 26},
+		{date(2000, 2, 29), date(2028, 2, 29), 28},
+	}
+	for _, tt := range tests {
+		if got := AgeAt(tt.birth, tt.now); got != tt.want {
+			t.Errorf("AgeAt(%s, %s) = %d, want %d", tt.birth.Format("2006-01-02"), tt.now.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package professional
+
+import "gorm.io/gorm"
+
+// Migrate migra a tabela professionals. O CPF era gravado com a máscara em
+// varchar(14): os valores existentes são reduzidos aos dígitos antes de a
+// coluna passar a varchar(11), senão o ALTER falharia. O UPDATE é direto no
+// banco para não passar pelo BeforeSave.
+func Migrate(db *gorm.DB) error {
+	if db.Migrator().HasTable(&Professional{}) {
+		if err := db.Exec(`UPDATE professionals SET cpf = regexp_replace(cpf, '\D', '', 'g') WHERE cpf ~ '\D'`).Error; err != nil {
+			return err
+		}
+		if err := db.Migrator().AlterColumn(&Professional{}, "CPF"); err != nil {
+			return err
+		}
+	}
+	return db.AutoMigrate(&Professional{})
+}
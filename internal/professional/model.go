@@ -1,7 +1,17 @@
 package professional
 
 import (
+	"errors"
 	"time"
+
+	"sonnda-api/internal/brdocs"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCPF = errors.New("invalid CPF")
+	ErrInvalidCNS = errors.New("invalid CNS")
 )
 
 // Profession representa o papel do usuário no sistema.
@@ -14,9 +24,18 @@ const (
 	ProfessionTecEnf     Profession = "tecnico_enfermagem"
 )
 
+// Valid informa se a profissão é uma das reconhecidas pelo sistema.
+func (p Profession) Valid() bool {
+	switch p {
+	case ProfessionMedico, ProfessionEnfermeiro, ProfessionACS, ProfessionTecEnf:
+		return true
+	}
+	return false
+}
+
 type Professional struct {
 	ID           int        `gorm:"primaryKey;autoIncrement" json:"id"`
-	CPF          string     `gorm:"size:14;not null;uniqueIndex" json:"cpf"` // ex: “123.456.789-00”
+	CPF          string     `gorm:"size:11;not null;uniqueIndex" json:"cpf"` // só dígitos, como em PatientProfile
 	CNS          *string    `gorm:"size:15" json:"cns,omitempty"`
 	FullName     string     `gorm:"size:255;not null" json:"full_name"`
 	PasswordHash string     `gorm:"size:255;not null" json:"-"`
@@ -26,3 +45,20 @@ type Professional struct {
 	CreatedAt    *time.Time `gorm:"autoCreateTime" json:"created_at,omitempty"`
 	UpdatedAt    *time.Time `gorm:"autoUpdateTime" json:"updated_at,omitempty"`
 }
+
+// BeforeSave recusa CPF/CNS inválidos e os normaliza para só dígitos; use
+// brdocs.FormatCPF para exibir.
+func (p *Professional) BeforeSave(tx *gorm.DB) error {
+	if !brdocs.ValidCPF(p.CPF) {
+		return ErrInvalidCPF
+	}
+	p.CPF = brdocs.NormalizeCPF(p.CPF)
+	if p.CNS != nil {
+		if !brdocs.ValidCNS(*p.CNS) {
+			return ErrInvalidCNS
+		}
+		cns := brdocs.NormalizeCNS(*p.CNS)
+		p.CNS = &cns
+	}
+	return nil
+}
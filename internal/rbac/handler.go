@@ -0,0 +1,75 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+
+	"sonnda-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+type grantRequest struct {
+	Permission Permission `json:"permission" binding:"required"`
+}
+
+// ListPermissions trata GET /admin/permissions
+func (h *Handler) ListPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"permissions": AllPermissions})
+}
+
+// ListGrants trata GET /admin/roles
+func (h *Handler) ListGrants(c *gin.Context) {
+	grants, err := h.svc.ListGrants(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": grants})
+}
+
+// Grant trata POST /admin/roles/:profile/permissions
+func (h *Handler) Grant(c *gin.Context) {
+	var req grantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_body", "details": err.Error()})
+		return
+	}
+
+	uid, _ := middleware.GetUserID(c)
+	if err := h.svc.Grant(c, c.Param("profile"), req.Permission, uid); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Revoke trata DELETE /admin/roles/:profile/permissions/:permission
+func (h *Handler) Revoke(c *gin.Context) {
+	if err := h.svc.Revoke(c, c.Param("profile"), Permission(c.Param("permission"))); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_profile"})
+	case errors.Is(err, ErrInvalidPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_permission"})
+	case errors.Is(err, ErrProtectedGrant):
+		c.JSON(http.StatusConflict, gin.H{"error": "protected_grant"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+	}
+}
@@ -0,0 +1,89 @@
+package rbac
+
+import (
+	"time"
+
+	"sonnda-api/internal/professional"
+	"sonnda-api/internal/user"
+)
+
+// Permission é uma ação autorizável no formato "recurso:ação".
+type Permission string
+
+const (
+	PermPatientRead  Permission = "patient:read"  // dados cadastrais de pacientes
+	PermPatientWrite Permission = "patient:write" // editar cadastro de pacientes
+	PermRecordRead   Permission = "record:read"   // prontuário (medical records)
+	PermRecordWrite  Permission = "record:write"  // registrar no prontuário
+	PermExamRead     Permission = "exam:read"     // laudos e resultados de exames
+	PermExamWrite    Permission = "exam:write"    // enviar e revisar exames
+	PermProfileSelf  Permission = "profile:self"  // gerenciar o próprio perfil de paciente
+	PermUserAdmin    Permission = "user:admin"    // usuários, convites, bloqueios e permissões
+
+	PermEmergencyAccess Permission = "patient:emergency" // acesso de emergência sem consentimento
+)
+
+// AllPermissions lista as permissões conhecidas pelo sistema.
+var AllPermissions = []Permission{
+	PermPatientRead, PermPatientWrite,
+	PermRecordRead, PermRecordWrite,
+	PermExamRead, PermExamWrite,
+	PermProfileSelf,
+	PermUserAdmin,
+	PermEmergencyAccess,
+}
+
+// Valid informa se a permissão é conhecida.
+func (p Permission) Valid() bool {
+	for _, known := range AllPermissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleGrant concede uma permissão a um perfil. O perfil é uma role
+// (PATIENT, DOCTOR, ADMIN) ou uma profissão (enfermeiro, acs, ...).
+type RoleGrant struct {
+	Profile    string     `gorm:"primaryKey;size:30" json:"profile"`
+	Permission Permission `gorm:"primaryKey;size:50" json:"permission"`
+	GrantedBy  *uint      `json:"grantedBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// ValidProfile informa se o perfil corresponde a uma role ou profissão existente.
+func ValidProfile(profile string) bool {
+	switch user.Role(profile) {
+	case user.RolePatient, user.RoleDoctor, user.RoleAdmin:
+		return true
+	}
+	return professional.Profession(profile).Valid()
+}
+
+// defaultGrants é a política inicial, gravada quando a tabela está vazia.
+// Profissionais de enfermagem e ACS têm acesso mais restrito que médicos;
+// o acesso de emergência é só da profissão médico.
+var defaultGrants = map[string][]Permission{
+	string(user.RolePatient): {PermProfileSelf},
+	string(user.RoleDoctor): {
+		PermPatientRead, PermPatientWrite,
+		PermRecordRead, PermRecordWrite,
+		PermExamRead, PermExamWrite,
+	},
+	string(user.RoleAdmin): {PermUserAdmin, PermPatientRead, PermRecordRead, PermExamRead},
+
+	string(professional.ProfessionMedico): {
+		PermPatientRead, PermPatientWrite,
+		PermRecordRead, PermRecordWrite,
+		PermExamRead, PermExamWrite,
+		PermEmergencyAccess,
+	},
+	string(professional.ProfessionEnfermeiro): {PermPatientRead, PermRecordRead, PermRecordWrite, PermExamRead},
+	string(professional.ProfessionTecEnf):     {PermPatientRead, PermRecordRead, PermExamRead},
+	string(professional.ProfessionACS):        {PermPatientRead},
+}
+
+// addedPermissions surgiram depois da primeira versão da política. Bancos já
+// semeados recebem as concessões padrão delas enquanto ninguém as tiver.
+var addedPermissions = []Permission{PermEmergencyAccess}
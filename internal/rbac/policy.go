@@ -0,0 +1,109 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"sonnda-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Policy resolve as permissões de um perfil a partir das concessões no banco,
+// mantidas em cache por ttl. Alterações feitas nesta réplica invalidam o cache
+// na hora; as demais réplicas enxergam a mudança em até ttl.
+type Policy struct {
+	repo Repository
+	ttl  time.Duration
+
+	mu       sync.RWMutex
+	grants   map[string]map[Permission]bool
+	loadedAt time.Time
+	gen      uint64 // incrementado por Invalidate
+}
+
+func NewPolicy(repo Repository, ttl time.Duration) *Policy {
+	return &Policy{repo: repo, ttl: ttl}
+}
+
+// Allows informa se o perfil tem a permissão.
+func (p *Policy) Allows(ctx context.Context, profile string, perm Permission) (bool, error) {
+	grants, err := p.load(ctx)
+	if err != nil {
+		return false, err
+	}
+	return grants[profile][perm], nil
+}
+
+// Invalidate força a releitura das concessões na próxima consulta.
+func (p *Policy) Invalidate() {
+	p.mu.Lock()
+	p.loadedAt = time.Time{}
+	p.gen++
+	p.mu.Unlock()
+}
+
+func (p *Policy) load(ctx context.Context) (map[string]map[Permission]bool, error) {
+	p.mu.RLock()
+	if p.grants != nil && time.Since(p.loadedAt) < p.ttl {
+		grants := p.grants
+		p.mu.RUnlock()
+		return grants, nil
+	}
+	gen := p.gen
+	p.mu.RUnlock()
+
+	list, err := p.repo.ListGrants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	grants := make(map[string]map[Permission]bool)
+	for _, g := range list {
+		if grants[g.Profile] == nil {
+			grants[g.Profile] = make(map[Permission]bool)
+		}
+		grants[g.Profile][g.Permission] = true
+	}
+
+	// uma alteração durante a leitura pode ter ficado de fora: não guarda,
+	// para que a próxima consulta releia
+	p.mu.Lock()
+	if p.gen == gen {
+		p.grants = grants
+		p.loadedAt = time.Now()
+	}
+	p.mu.Unlock()
+	return grants, nil
+}
+
+// Require retorna um middleware que exige todas as permissões informadas.
+// O perfil vem do Principal (role ou profissão do token), sem consulta ao
+// usuário no banco.
+func (p *Policy) Require(perms ...Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := middleware.CurrentPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		profile := principal.Profile()
+		for _, perm := range perms {
+			allowed, err := p.Allows(c, profile, perm)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+				return
+			}
+			if !allowed {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error":               "insufficient_permissions",
+					"required_permission": perm,
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,148 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sonnda-api/internal/middleware"
+	"sonnda-api/internal/professional"
+	"sonnda-api/internal/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeRepo struct {
+	grants  []RoleGrant
+	reads   int
+	onRead  func() // chamado no meio da leitura
+	revoked []RoleGrant
+}
+
+func (r *fakeRepo) ListGrants(ctx context.Context) ([]RoleGrant, error) {
+	r.reads++
+	out := append([]RoleGrant(nil), r.grants...)
+	if r.onRead != nil {
+		r.onRead()
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) Grant(ctx context.Context, g *RoleGrant) error {
+	r.grants = append(r.grants, *g)
+	return nil
+}
+
+func (r *fakeRepo) Revoke(ctx context.Context, profile string, perm Permission) error {
+	kept := r.grants[:0]
+	for _, g := range r.grants {
+		if g.Profile == profile && g.Permission == perm {
+			r.revoked = append(r.revoked, g)
+			continue
+		}
+		kept = append(kept, g)
+	}
+	r.grants = kept
+	return nil
+}
+
+func (r *fakeRepo) SeedDefaults(ctx context.Context) error { return nil }
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{grants: []RoleGrant{
+		{Profile: string(professional.ProfessionMedico), Permission: PermPatientRead},
+		{Profile: string(professional.ProfessionMedico), Permission: PermRecordRead},
+		{Profile: string(professional.ProfessionACS), Permission: PermPatientRead},
+		{Profile: string(user.RoleAdmin), Permission: PermUserAdmin},
+	}}
+}
+
+func TestPolicyRequire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := NewPolicy(newFakeRepo(), time.Minute)
+	medico := &middleware.Principal{UserID: 1, Role: user.RoleDoctor, Profession: professional.ProfessionMedico}
+	acs := &middleware.Principal{UserID: 2, Role: user.RoleDoctor, Profession: professional.ProfessionACS}
+
+	cases := []struct {
+		name      string
+		principal *middleware.Principal
+		perms     []Permission
+		want      int
+	}{
+		{"sem principal", nil, []Permission{PermPatientRead}, http.StatusUnauthorized},
+		{"permitido", medico, []Permission{PermPatientRead, PermRecordRead}, http.StatusOK},
+		{"uma das permissões falta", acs, []Permission{PermPatientRead, PermRecordRead}, http.StatusForbidden},
+		{"perfil sem concessões", &middleware.Principal{UserID: 3, Role: user.RolePatient}, []Permission{PermPatientRead}, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, r := gin.CreateTestContext(w)
+		r.GET("/", func(c *gin.Context) {
+			if tc.principal != nil {
+				middleware.SetPrincipal(c, tc.principal)
+			}
+			c.Next()
+		}, policy.Require(tc.perms...), func(c *gin.Context) { c.Status(http.StatusOK) })
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.HandleContext(c)
+		if w.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, w.Code, tc.want)
+		}
+	}
+}
+
+func TestPolicyCache(t *testing.T) {
+	repo := newFakeRepo()
+	policy := NewPolicy(repo, time.Minute)
+	ctx := context.Background()
+	medico := string(professional.ProfessionMedico)
+
+	for range 3 {
+		if ok, _ := policy.Allows(ctx, medico, PermPatientRead); !ok {
+			t.Fatal("granted permission denied")
+		}
+	}
+	if repo.reads != 1 {
+		t.Fatalf("%d reads within ttl, want 1", repo.reads)
+	}
+
+	// Revoke nesta réplica invalida o cache na hora
+	svc := NewService(repo, policy)
+	if err := svc.Revoke(ctx, medico, PermPatientRead); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := policy.Allows(ctx, medico, PermPatientRead); ok {
+		t.Fatal("revoked permission still allowed")
+	}
+
+	// ttl vencido relê
+	expiring := NewPolicy(repo, time.Nanosecond)
+	expiring.Allows(ctx, medico, PermRecordRead)
+	time.Sleep(time.Millisecond)
+	reads := repo.reads
+	expiring.Allows(ctx, medico, PermRecordRead)
+	if repo.reads != reads+1 {
+		t.Fatal("expired cache not reloaded")
+	}
+}
+
+func TestPolicyInvalidateDuringLoad(t *testing.T) {
+	repo := newFakeRepo()
+	policy := NewPolicy(repo, time.Hour)
+	ctx := context.Background()
+	medico := string(professional.ProfessionMedico)
+
+	// a revogação acontece depois da leitura do banco e antes de o cache ser gravado
+	repo.onRead = func() {
+		repo.onRead = nil
+		repo.Revoke(ctx, medico, PermPatientRead)
+		policy.Invalidate()
+	}
+	policy.Allows(ctx, medico, PermPatientRead)
+
+	if ok, _ := policy.Allows(ctx, medico, PermPatientRead); ok {
+		t.Fatal("stale grants cached after a concurrent revoke")
+	}
+}
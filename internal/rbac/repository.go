@@ -0,0 +1,84 @@
+package rbac
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	ListGrants(ctx context.Context) ([]RoleGrant, error)
+	Grant(ctx context.Context, g *RoleGrant) error
+	Revoke(ctx context.Context, profile string, perm Permission) error
+	SeedDefaults(ctx context.Context) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) ListGrants(ctx context.Context) ([]RoleGrant, error) {
+	var grants []RoleGrant
+	err := r.db.WithContext(ctx).
+		Order("profile, permission").
+		Find(&grants).Error
+	return grants, err
+}
+
+func (r *repository) Grant(ctx context.Context, g *RoleGrant) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(g).Error
+}
+
+func (r *repository) Revoke(ctx context.Context, profile string, perm Permission) error {
+	return r.db.WithContext(ctx).
+		Where("profile = ? AND permission = ?", profile, perm).
+		Delete(&RoleGrant{}).Error
+}
+
+// SeedDefaults grava a política padrão apenas se ainda não houver nenhuma
+// concessão, para não desfazer ajustes feitos pelos admins. Numa política
+// já gravada, só entram as permissões novas que ainda não têm concessões.
+func (r *repository) SeedDefaults(ctx context.Context) error {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&RoleGrant{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return r.seed(ctx, nil)
+	}
+
+	for _, perm := range addedPermissions {
+		if err := r.db.WithContext(ctx).Model(&RoleGrant{}).Where("permission = ?", perm).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := r.seed(ctx, &perm); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// seed grava as concessões padrão; only restringe a uma permissão.
+func (r *repository) seed(ctx context.Context, only *Permission) error {
+	var grants []RoleGrant
+	for profile, perms := range defaultGrants {
+		for _, p := range perms {
+			if only == nil || p == *only {
+				grants = append(grants, RoleGrant{Profile: profile, Permission: p})
+			}
+		}
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&grants).Error
+}
@@ -0,0 +1,30 @@
+package rbac
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Routes registra a gestão de permissões por perfil, restrita a quem tem user:admin.
+func Routes(rg *gin.RouterGroup, db *gorm.DB, authenticate gin.HandlerFunc, policy *Policy) {
+	repo := NewRepository(db)
+	svc := NewService(repo, policy)
+	handler := NewHandler(svc)
+
+	admin := rg.Group("/admin")
+	admin.Use(authenticate)
+	admin.Use(policy.Require(PermUserAdmin))
+	{
+		// GET /api/v1/admin/permissions
+		admin.GET("/permissions", handler.ListPermissions)
+
+		// GET /api/v1/admin/roles
+		admin.GET("/roles", handler.ListGrants)
+
+		// POST /api/v1/admin/roles/:profile/permissions
+		admin.POST("/roles/:profile/permissions", handler.Grant)
+
+		// DELETE /api/v1/admin/roles/:profile/permissions/:permission
+		admin.DELETE("/roles/:profile/permissions/:permission", handler.Revoke)
+	}
+}
@@ -0,0 +1,72 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+
+	"sonnda-api/internal/user"
+)
+
+var (
+	ErrInvalidProfile    = errors.New("invalid profile")
+	ErrInvalidPermission = errors.New("invalid permission")
+	ErrProtectedGrant    = errors.New("grant cannot be revoked")
+)
+
+type Service interface {
+	ListGrants(ctx context.Context) (map[string][]Permission, error)
+	Grant(ctx context.Context, profile string, perm Permission, grantedBy uint) error
+	Revoke(ctx context.Context, profile string, perm Permission) error
+}
+
+type service struct {
+	repo   Repository
+	policy *Policy
+}
+
+func NewService(repo Repository, policy *Policy) Service {
+	return &service{repo: repo, policy: policy}
+}
+
+// ListGrants retorna as permissões agrupadas por perfil
+func (s *service) ListGrants(ctx context.Context) (map[string][]Permission, error) {
+	grants, err := s.repo.ListGrants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]Permission)
+	for _, g := range grants {
+		out[g.Profile] = append(out[g.Profile], g.Permission)
+	}
+	return out, nil
+}
+
+func (s *service) Grant(ctx context.Context, profile string, perm Permission, grantedBy uint) error {
+	if !ValidProfile(profile) {
+		return ErrInvalidProfile
+	}
+	if !perm.Valid() {
+		return ErrInvalidPermission
+	}
+	if err := s.repo.Grant(ctx, &RoleGrant{Profile: profile, Permission: perm, GrantedBy: &grantedBy}); err != nil {
+		return err
+	}
+	s.policy.Invalidate()
+	return nil
+}
+
+// Revoke remove uma permissão do perfil. A administração de usuários não pode
+// ser retirada dos admins, senão ninguém mais conseguiria desfazer a mudança.
+func (s *service) Revoke(ctx context.Context, profile string, perm Permission) error {
+	if !ValidProfile(profile) {
+		return ErrInvalidProfile
+	}
+	if profile == string(user.RoleAdmin) && perm == PermUserAdmin {
+		return ErrProtectedGrant
+	}
+	if err := s.repo.Revoke(ctx, profile, perm); err != nil {
+		return err
+	}
+	s.policy.Invalidate()
+	return nil
+}
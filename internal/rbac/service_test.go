@@ -0,0 +1,36 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sonnda-api/internal/professional"
+	"sonnda-api/internal/user"
+)
+
+func TestServiceRevoke(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name    string
+		profile string
+		perm    Permission
+		want    error
+	}{
+		{"admin não perde user:admin", string(user.RoleAdmin), PermUserAdmin, ErrProtectedGrant},
+		{"perfil inválido", "astronauta", PermPatientRead, ErrInvalidProfile},
+		{"permissão comum", string(professional.ProfessionACS), PermPatientRead, nil},
+	}
+	for _, tc := range cases {
+		repo := newFakeRepo()
+		svc := NewService(repo, NewPolicy(repo, time.Minute))
+		err := svc.Revoke(ctx, tc.profile, tc.perm)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+		if tc.want != nil && len(repo.revoked) != 0 {
+			t.Errorf("%s: grant removed despite the error", tc.name)
+		}
+	}
+}
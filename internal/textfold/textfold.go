@@ -0,0 +1,27 @@
+// Package textfold normaliza texto em português para comparações que
+// ignoram acentos e caixa (busca no CID-10, regras de prevenção, laudos).
+package textfold
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// RemoveAccents remove acentos ("mês" → "mes"), mantendo a caixa.
+func RemoveAccents(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	out, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
+// Fold remove acentos e passa para minúsculas.
+func Fold(s string) string {
+	return strings.ToLower(RemoveAccents(s))
+}
@@ -0,0 +1,21 @@
+package textfold
+
+import "testing"
+
+func TestFold(t *testing.T) {
+	tests := []struct{ in, accents, fold string }{
+		{"mês", "mes", "mes"},
+		{"Hemoglobina Glicada", "Hemoglobina Glicada", "hemoglobina glicada"},
+		{"VACINAÇÃO CONTRA INFLUENZA", "VACINACAO CONTRA INFLUENZA", "vacinacao contra influenza"},
+		{"Úlcera gástrica", "Ulcera gastrica", "ulcera gastrica"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := RemoveAccents(tt.in); got != tt.accents {
+			t.Errorf("RemoveAccents(%q) = %q, want %q", tt.in, got, tt.accents)
+		}
+		if got := Fold(tt.in); got != tt.fold {
+			t.Errorf("Fold(%q) = %q, want %q", tt.in, got, tt.fold)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package user
 
-import "time"
+import (
+	"time"
+
+	"sonnda-api/internal/professional"
+)
 
 type Role string
 
@@ -11,10 +15,18 @@ const (
 )
 
 type User struct {
-	ID           uint      `gorm:"primaryKey"`
-	Email        string    `gorm:"uniqueIndex;not null"`
-	PasswordHash string    `gorm:"not null"`
-	Role         Role      `gorm:"type:varchar(20);not null"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID                      uint                     `gorm:"primaryKey"`
+	Email                   string                   `gorm:"uniqueIndex;not null"`
+	PasswordHash            string                   `gorm:"not null"`
+	Role                    Role                     `gorm:"type:varchar(20);not null"`
+	Profession              *professional.Profession `gorm:"type:varchar(30)" json:"profession,omitempty"`
+	EmailVerifiedAt         *time.Time               `json:"emailVerifiedAt,omitempty"`
+	EmailVerificationSentAt *time.Time               `json:"-"` // usado para limitar reenvios
+	CreatedAt               time.Time                `json:"createdAt"`
+	UpdatedAt               time.Time                `json:"updatedAt"`
+}
+
+// IsEmailVerified informa se o usuário já confirmou o e-mail.
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }